@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KevinWu0904/crond/proto/types"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// clusterConfig stores crond cluster command configurations.
+var clusterConfig = struct {
+	Server   string
+	Timeout  time.Duration
+	ID       string
+	Address  string
+	Nonvoter bool
+}{
+	Server:  "127.0.0.1:5281",
+	Timeout: time.Second * 30,
+}
+
+// ClusterCommand represents crond cluster CLI.
+var ClusterCommand = &cobra.Command{
+	Use:   "cluster",
+	Short: "CronD cluster manages raft cluster membership",
+	Long:  "CronD cluster manages raft cluster membership through any server in the cluster, changes are forwarded to the raft leader",
+}
+
+// ClusterJoinCommand represents crond cluster join CLI.
+var ClusterJoinCommand = &cobra.Command{
+	Use:   "join",
+	Short: "Add a server into the cluster",
+	Long:  "Add a server into the cluster as a voter, or as a nonvoter which only replicates logs",
+	Args:  cobra.NoArgs,
+	RunE:  RunClusterJoin,
+}
+
+// ClusterLeaveCommand represents crond cluster leave CLI.
+var ClusterLeaveCommand = &cobra.Command{
+	Use:   "leave",
+	Short: "Remove a server from the cluster",
+	Args:  cobra.NoArgs,
+	RunE:  RunClusterLeave,
+}
+
+// ClusterMembersCommand represents crond cluster members CLI.
+var ClusterMembersCommand = &cobra.Command{
+	Use:   "members",
+	Short: "List servers in the cluster",
+	Args:  cobra.NoArgs,
+	RunE:  RunClusterMembers,
+}
+
+// ClusterStatusCommand represents crond cluster status CLI.
+var ClusterStatusCommand = &cobra.Command{
+	Use:   "status",
+	Short: "Show raft and dispatcher state of the server",
+	Args:  cobra.NoArgs,
+	RunE:  RunClusterStatus,
+}
+
+func bindClusterFlags() {
+	ClusterCommand.PersistentFlags().StringVar(&clusterConfig.Server, "server", clusterConfig.Server,
+		"address of any crond server in the cluster")
+	ClusterCommand.PersistentFlags().DurationVar(&clusterConfig.Timeout, "timeout", clusterConfig.Timeout, "timeout of the cluster request")
+
+	ClusterJoinCommand.Flags().StringVar(&clusterConfig.ID, "id", "", "raft node name of the joining server")
+	ClusterJoinCommand.Flags().StringVar(&clusterConfig.Address, "address", "", "raft advertise address of the joining server")
+	ClusterJoinCommand.Flags().BoolVar(&clusterConfig.Nonvoter, "nonvoter", false, "if true, the server joins as a nonvoter")
+	_ = ClusterJoinCommand.MarkFlagRequired("id")
+	_ = ClusterJoinCommand.MarkFlagRequired("address")
+
+	ClusterLeaveCommand.Flags().StringVar(&clusterConfig.ID, "id", "", "raft node name of the leaving server")
+	_ = ClusterLeaveCommand.MarkFlagRequired("id")
+
+	ClusterCommand.AddCommand(ClusterJoinCommand, ClusterLeaveCommand, ClusterMembersCommand, ClusterStatusCommand)
+}
+
+// RunClusterJoin adds a server into the cluster.
+func RunClusterJoin(cmd *cobra.Command, args []string) error {
+	return withClusterClient(cmd, func(ctx context.Context, client types.CrondClient) error {
+		var err error
+		if clusterConfig.Nonvoter {
+			_, err = client.AddNonvoter(ctx, &types.AddNonvoterRequest{Id: clusterConfig.ID, Address: clusterConfig.Address})
+		} else {
+			_, err = client.AddVoter(ctx, &types.AddVoterRequest{Id: clusterConfig.ID, Address: clusterConfig.Address})
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stdout, "server %s joined the cluster: address=%s\n", clusterConfig.ID, clusterConfig.Address)
+		return nil
+	})
+}
+
+// RunClusterLeave removes a server from the cluster.
+func RunClusterLeave(cmd *cobra.Command, args []string) error {
+	return withClusterClient(cmd, func(ctx context.Context, client types.CrondClient) error {
+		if _, err := client.RemoveServer(ctx, &types.RemoveServerRequest{Id: clusterConfig.ID}); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stdout, "server %s left the cluster\n", clusterConfig.ID)
+		return nil
+	})
+}
+
+// RunClusterMembers lists servers in the cluster.
+func RunClusterMembers(cmd *cobra.Command, args []string) error {
+	return withClusterClient(cmd, func(ctx context.Context, client types.CrondClient) error {
+		resp, err := client.ListPeers(ctx, &types.ListPeersRequest{})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tADDRESS\tSUFFRAGE\tLEADER")
+		for _, peer := range resp.GetPeers() {
+			suffrage := strings.ToLower(strings.TrimPrefix(peer.GetSuffrage().String(), "PEER_SUFFRAGE_"))
+			fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", peer.GetId(), peer.GetAddress(), suffrage, peer.GetLeader())
+		}
+		return w.Flush()
+	})
+}
+
+// RunClusterStatus shows raft and dispatcher state of the server.
+func RunClusterStatus(cmd *cobra.Command, args []string) error {
+	return withClusterClient(cmd, func(ctx context.Context, client types.CrondClient) error {
+		resp, err := client.GetClusterStatus(ctx, &types.GetClusterStatusRequest{})
+		if err != nil {
+			return err
+		}
+
+		status := resp.GetStatus()
+		lastContact := "-"
+		if status.GetLastContact() != nil {
+			lastContact = time.Since(status.GetLastContact().AsTime()).Round(time.Millisecond).String() + " ago"
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "ID:\t%s\n", status.GetId())
+		fmt.Fprintf(w, "Address:\t%s\n", status.GetAddress())
+		fmt.Fprintf(w, "Role:\t%s\n", strings.ToLower(strings.TrimPrefix(status.GetRole().String(), "RAFT_ROLE_")))
+		fmt.Fprintf(w, "Leader:\t%s\n", status.GetLeaderAddress())
+		fmt.Fprintf(w, "Term:\t%d\n", status.GetTerm())
+		fmt.Fprintf(w, "Commit Index:\t%d\n", status.GetCommitIndex())
+		fmt.Fprintf(w, "Applied Index:\t%d\n", status.GetAppliedIndex())
+		fmt.Fprintf(w, "Last Contact:\t%s\n", lastContact)
+		fmt.Fprintf(w, "Peers:\t%d\n", len(status.GetPeers()))
+		fmt.Fprintf(w, "Ready:\t%t\n", status.GetReady())
+		fmt.Fprintf(w, "Dispatcher:\tstarted=%t, jobs=%d, running_runs=%d\n", status.GetDispatcher().GetStarted(),
+			status.GetDispatcher().GetJobs(), status.GetDispatcher().GetRunningRuns())
+		return w.Flush()
+	})
+}
+
+// withClusterClient calls fn with a CrondClient connected to the server, usage is not printed for request failures.
+func withClusterClient(cmd *cobra.Command, fn func(ctx context.Context, client types.CrondClient) error) error {
+	cmd.SilenceUsage = true
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), clusterConfig.Timeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, clusterConfig.Server, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return fmt.Errorf("failed to connect %s: %v", clusterConfig.Server, err)
+	}
+	defer conn.Close()
+
+	return fn(ctx, types.NewCrondClient(conn))
+}
@@ -29,6 +29,7 @@ func init() {
 
 	// Add crond sub commands.
 	RootCommand.AddCommand(ServerCommand)
+	RootCommand.AddCommand(ClusterCommand)
 
 	// Bind crond global config file.
 	RootCommand.PersistentFlags().StringVarP(&configFile, "config", "c", "", "server global config file")
@@ -36,6 +37,7 @@ func init() {
 	// Bind crond extra flags to related commands.
 	bindRootFlags()
 	bindServerFlags()
+	bindClusterFlags()
 }
 
 func bindRootFlags() {
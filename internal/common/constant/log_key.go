@@ -1,5 +1,7 @@
 package constant
 
 const (
-	LogJobKey string = "job_key"
+	LogJobKey  string = "job_key"
+	LogTraceID string = "trace_id"
+	LogSpanID  string = "span_id"
 )
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/KevinWu0904/crond/pkg/logs"
+	"github.com/KevinWu0904/crond/proto/types"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrNotCaughtUp throws when the current node has not applied all committed raft logs yet.
+var ErrNotCaughtUp = errors.New("raft logs are not caught up")
+
+// ClusterStatus represents the state of the current node in the cluster.
+type ClusterStatus struct {
+	ID         string
+	Address    string
+	Raft       *RaftStatus
+	Peers      []*Peer
+	Dispatcher *DispatcherStatus
+	Ready      bool
+}
+
+// ToProto converts ClusterStatus into types.ClusterStatus.
+func (s *ClusterStatus) ToProto() *types.ClusterStatus {
+	pb := &types.ClusterStatus{
+		Id:            s.ID,
+		Address:       s.Address,
+		Role:          types.RaftRole(s.Raft.Role),
+		Term:          s.Raft.Term,
+		CommitIndex:   s.Raft.CommitIndex,
+		AppliedIndex:  s.Raft.AppliedIndex,
+		LeaderAddress: string(s.Raft.LeaderAddr),
+		Dispatcher:    s.Dispatcher.ToProto(),
+		Ready:         s.Ready,
+	}
+	if !s.Raft.LastContact.IsZero() {
+		pb.LastContact = timestamppb.New(s.Raft.LastContact)
+	}
+	for _, peer := range s.Peers {
+		pb.Peers = append(pb.Peers, peer.ToProto())
+	}
+
+	return pb
+}
+
+// ClusterService implements crond cluster membership management and status, it is shared by all protocol services.
+type ClusterService struct {
+	raftLayer  *RaftLayer
+	dispatcher *CronDispatcher
+}
+
+// NewClusterService creates ClusterService.
+func NewClusterService(raftLayer *RaftLayer, dispatcher *CronDispatcher) *ClusterService {
+	return &ClusterService{
+		raftLayer:  raftLayer,
+		dispatcher: dispatcher,
+	}
+}
+
+// AddVoter adds a voting server through raft layer, adding an existing server updates its address and suffrage.
+func (s *ClusterService) AddVoter(ctx context.Context, id, addr string) error {
+	if err := s.raftLayer.AddVoter(id, addr); err != nil {
+		logs.CtxError(ctx, "AddVoter failed: id=%s, addr=%s, err=%v", id, addr, err)
+		return err
+	}
+
+	logs.CtxInfo(ctx, "AddVoter successfully: id=%s, addr=%s", id, addr)
+	return nil
+}
+
+// AddNonvoter adds a server which only replicates logs through raft layer, it does not take part in elections.
+func (s *ClusterService) AddNonvoter(ctx context.Context, id, addr string) error {
+	if err := s.raftLayer.AddNonvoter(id, addr); err != nil {
+		logs.CtxError(ctx, "AddNonvoter failed: id=%s, addr=%s, err=%v", id, addr, err)
+		return err
+	}
+
+	logs.CtxInfo(ctx, "AddNonvoter successfully: id=%s, addr=%s", id, addr)
+	return nil
+}
+
+// RemoveServer removes a server through raft layer, removing the leader itself makes it step down.
+func (s *ClusterService) RemoveServer(ctx context.Context, id string) error {
+	if err := s.raftLayer.RemoveServer(id); err != nil {
+		logs.CtxError(ctx, "RemoveServer failed: id=%s, err=%v", id, err)
+		return err
+	}
+
+	logs.CtxInfo(ctx, "RemoveServer successfully: id=%s", id)
+	return nil
+}
+
+// ListPeers reads the latest raft configuration known by the current node.
+func (s *ClusterService) ListPeers(ctx context.Context) ([]*Peer, error) {
+	return s.raftLayer.Peers()
+}
+
+// Status reads the state of the current node, it is served locally so every node can be inspected.
+func (s *ClusterService) Status(ctx context.Context) (*ClusterStatus, error) {
+	peers, err := s.raftLayer.Peers()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ClusterStatus{
+		ID:         string(s.raftLayer.LocalID()),
+		Address:    string(s.raftLayer.LocalAddr()),
+		Raft:       s.raftLayer.Status(),
+		Peers:      peers,
+		Dispatcher: s.dispatcher.Status(),
+	}
+	status.Ready = status.Raft.ready() == nil
+
+	return status, nil
+}
+
+// Ready reports whether the current node can serve requests: it knows raft leader and has applied all committed logs,
+// so reads from JobFSM are up to date. It fails with ErrNoLeader or ErrNotCaughtUp otherwise.
+func (s *ClusterService) Ready(ctx context.Context) error {
+	return s.raftLayer.Status().ready()
+}
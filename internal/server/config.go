@@ -1,18 +1,30 @@
 package server
 
 import (
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/pflag"
 )
 
 // Config stores all crond server configurations.
 type Config struct {
-	ServerPort     int    `mapstructure:"server-port"`
-	RaftProduction bool   `mapstructure:"raft-production"`
-	RaftNode       string `mapstructure:"raft-node"`
-	RaftBootstrap  bool   `mapstructure:"raft-bootstrap"`
-	RaftDataDir    string `mapstructure:"raft-data-dir"`
+	ServerPort           int           `mapstructure:"server-port"`
+	RaftProduction       bool          `mapstructure:"raft-production"`
+	RaftNode             string        `mapstructure:"raft-node"`
+	RaftBootstrap        bool          `mapstructure:"raft-bootstrap"`
+	RaftAdvertise        string        `mapstructure:"raft-advertise"`
+	RaftJoin             []string      `mapstructure:"raft-join"`
+	RaftDataDir          string        `mapstructure:"raft-data-dir"`
+	RunHistoryMax        int           `mapstructure:"run-history-max"`
+	WatchHistoryMax      int           `mapstructure:"watch-history-max"`
+	CompletedJobTTL      time.Duration `mapstructure:"completed-job-ttl"`
+	ShutdownDrainTimeout time.Duration `mapstructure:"shutdown-drain-timeout"`
+	TraceExporter        string        `mapstructure:"trace-exporter"`
+	TraceOTLPEndpoint    string        `mapstructure:"trace-otlp-endpoint"`
+	TraceOTLPInsecure    bool          `mapstructure:"trace-otlp-insecure"`
+	TraceSampleRatio     float64       `mapstructure:"trace-sample-ratio"`
 }
 
 // DefaultConfig creates the Config with sensible default settings.
@@ -23,19 +35,65 @@ func DefaultConfig() *Config {
 	}
 
 	return &Config{
-		ServerPort:     5281,
-		RaftProduction: false,
-		RaftNode:       name,
-		RaftBootstrap:  false,
-		RaftDataDir:    "data",
+		ServerPort:           5281,
+		RaftProduction:       false,
+		RaftNode:             name,
+		RaftBootstrap:        false,
+		RaftDataDir:          "data",
+		RunHistoryMax:        100,
+		WatchHistoryMax:      1000,
+		CompletedJobTTL:      time.Hour * 24,
+		ShutdownDrainTimeout: time.Second * 30,
+		TraceExporter:        TraceExporterNone,
+		TraceOTLPEndpoint:    "localhost:4317",
+		TraceOTLPInsecure:    false,
+		TraceSampleRatio:     1,
 	}
 }
 
+// Validate checks the configurations which would break crond server at runtime.
+func (c *Config) Validate() error {
+	if c.RunHistoryMax <= 0 {
+		return fmt.Errorf("run-history-max must be positive: %d", c.RunHistoryMax)
+	}
+	if c.WatchHistoryMax <= 0 {
+		return fmt.Errorf("watch-history-max must be positive: %d", c.WatchHistoryMax)
+	}
+	if c.CompletedJobTTL < 0 {
+		return fmt.Errorf("completed-job-ttl must not be negative: %v", c.CompletedJobTTL)
+	}
+	if c.ShutdownDrainTimeout <= 0 {
+		return fmt.Errorf("shutdown-drain-timeout must be positive: %v", c.ShutdownDrainTimeout)
+	}
+	if !(c.TraceSampleRatio >= 0 && c.TraceSampleRatio <= 1) {
+		return fmt.Errorf("trace-sample-ratio must be in range [0, 1]: %v", c.TraceSampleRatio)
+	}
+
+	return nil
+}
+
 // BindFlags overwrites default crond server configurations from CLI flags.
 func BindFlags(c *Config, fs *pflag.FlagSet) {
 	fs.IntVar(&c.ServerPort, "server-port", c.ServerPort, "server server port")
 	fs.BoolVar(&c.RaftProduction, "raft-production", c.RaftProduction, "if true, raft layer runs in production mode")
 	fs.StringVar(&c.RaftNode, "raft-node", c.RaftNode, "raft layer node name")
 	fs.BoolVar(&c.RaftBootstrap, "raft-bootstrap", c.RaftBootstrap, "if true, raft layer will bootstrap cluster")
+	fs.StringVar(&c.RaftAdvertise, "raft-advertise", c.RaftAdvertise,
+		"host:port other nodes reach this node at, it defaults to the listen address and must be set in multi-node clusters")
+	fs.StringSliceVar(&c.RaftJoin, "raft-join", c.RaftJoin,
+		"addresses of existing cluster nodes, a node without raft state joins the cluster through them on startup")
 	fs.StringVar(&c.RaftDataDir, "raft-data-dir", c.RaftDataDir, "raft layer persists data in this specific directory")
+	fs.IntVar(&c.RunHistoryMax, "run-history-max", c.RunHistoryMax,
+		"at most run-history-max runs will be kept for each job, it must be positive")
+	fs.IntVar(&c.WatchHistoryMax, "watch-history-max", c.WatchHistoryMax,
+		"at most watch-history-max latest events will be kept for watchers to resume from, it must be positive")
+	fs.DurationVar(&c.CompletedJobTTL, "completed-job-ttl", c.CompletedJobTTL,
+		"completed one-shot and ended jobs will be deleted after completed-job-ttl, it must not be negative")
+	fs.DurationVar(&c.ShutdownDrainTimeout, "shutdown-drain-timeout", c.ShutdownDrainTimeout,
+		"on shutdown the leader waits at most shutdown-drain-timeout for in-flight runs before transferring leadership, it must be positive")
+	fs.StringVar(&c.TraceExporter, "trace-exporter", c.TraceExporter, "trace exporter, one of none, stdout and otlp")
+	fs.StringVar(&c.TraceOTLPEndpoint, "trace-otlp-endpoint", c.TraceOTLPEndpoint, "OTLP gRPC endpoint which traces are exported to")
+	fs.BoolVar(&c.TraceOTLPInsecure, "trace-otlp-insecure", c.TraceOTLPInsecure, "if true, traces are exported to OTLP endpoint without TLS")
+	fs.Float64Var(&c.TraceSampleRatio, "trace-sample-ratio", c.TraceSampleRatio,
+		"ratio of new traces to be sampled in range [0, 1], traces started by callers follow their sampling decisions")
 }
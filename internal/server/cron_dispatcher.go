@@ -2,14 +2,106 @@ package server
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/KevinWu0904/crond/internal/common/constant"
 	"github.com/KevinWu0904/crond/pkg/logs"
+	"github.com/KevinWu0904/crond/proto/types"
 
 	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const (
+	// dispatcherKillTimeout is the maximum duration to wait for canceled runs exiting after a forcible shutdown.
+	dispatcherKillTimeout = time.Second * 5
+	// completedJobGCInterval is the interval to look for expired completed jobs.
+	completedJobGCInterval = time.Second * 10
+)
+
+// ErrDispatcherNotStarted throws when a job is triggered on a node whose CronDispatcher is not running.
+var ErrDispatcherNotStarted = errors.New("dispatcher is not started")
+
+// cronParser parses cron expressions with a leading seconds field, it is shared by job validation and CronDispatcher.
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// JobStore provides the replicated state which CronDispatcher starts from.
+type JobStore interface {
+	ListJobs() []*Job
+	ListRunningRuns() []*JobRun
+	ListPendingRetries() []*JobRun
+}
+
+// JobCompleter completes jobs after their final runs and deletes them once expired.
+type JobCompleter interface {
+	CompleteJob(ctx context.Context, jobID string) error
+	DeleteJob(ctx context.Context, jobID string) (*Job, error)
+}
+
+// jobEntry binds a job with its cron entry.
+type jobEntry struct {
+	EntryID cron.EntryID
+	Job     *Job
+}
+
+// dispatchScope holds the contexts of a single CronDispatcher running period between Start and Stop.
+type dispatchScope struct {
+	runCtx        context.Context // Canceled when Stop times out, it cancels in-flight runs.
+	cancelRuns    context.CancelFunc
+	retryCtx      context.Context // Canceled as soon as Stop is called, it cancels pending retries and catch-ups.
+	cancelRetries context.CancelFunc
+	tasks         sync.WaitGroup // Pending retries, catch-up runs and job completions.
+	store         JobStore
+
+	mu      sync.Mutex
+	running map[string]map[string]context.CancelFunc // JobID -> RunID -> cancel of the in-flight run.
+}
+
+// acquire registers the run as in-flight according to policy, it returns false if the run should be skipped.
+func (s *dispatchScope) acquire(run *JobRun, policy ConcurrencyPolicy) (context.Context, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := s.running[run.JobID]
+	switch policy {
+	case ConcurrencyPolicyForbid:
+		if len(runs) > 0 {
+			return nil, false
+		}
+	case ConcurrencyPolicyReplace:
+		for _, cancel := range runs {
+			cancel()
+		}
+	}
+
+	if runs == nil {
+		runs = make(map[string]context.CancelFunc)
+		s.running[run.JobID] = runs
+	}
+
+	ctx, cancel := context.WithCancel(s.runCtx)
+	runs[run.RunID] = cancel
+	return ctx, true
+}
+
+// release unregisters the in-flight run.
+func (s *dispatchScope) release(run *JobRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cancel, ok := s.running[run.JobID][run.RunID]; ok {
+		cancel()
+		delete(s.running[run.JobID], run.RunID)
+	}
+	if len(s.running[run.JobID]) == 0 {
+		delete(s.running, run.JobID)
+	}
+}
+
 // CronDispatcher represents crond unified job dispatcher which is expected to be running only in raft leader node.
 type CronDispatcher struct {
 	Cron       *cron.Cron
@@ -17,18 +109,31 @@ type CronDispatcher struct {
 
 	sync.Mutex
 
-	started bool
+	node            string
+	recorder        JobRunRecorder
+	completer       JobCompleter
+	completedJobTTL time.Duration
+	started         bool
+	scope           *dispatchScope
 }
 
-// NewCronDispatcher creates CronDispatcher.
-func NewCronDispatcher() *CronDispatcher {
+// NewCronDispatcher creates CronDispatcher, every run will be recorded with the node name by recorder. Jobs are
+// completed by completer after their final runs and deleted completedJobTTL later.
+func NewCronDispatcher(node string, recorder JobRunRecorder, completer JobCompleter, completedJobTTL time.Duration) *CronDispatcher {
 	return &CronDispatcher{
-		Cron: cron.New(cron.WithSeconds()),
+		Cron:            cron.New(cron.WithParser(cronParser)),
+		node:            node,
+		recorder:        recorder,
+		completer:       completer,
+		completedJobTTL: completedJobTTL,
 	}
 }
 
-// Start will load initial jobs from persistent storage and start CronDispatcher.
-func (cd *CronDispatcher) Start(ctx context.Context, initJobs []*Job) error {
+// Start will load initial jobs from persistent storage and start CronDispatcher, it also takes over runs left by
+// the previous leader and catches up fires missed during failover according to MisfirePolicy. Jobs whose schedules
+// have been exhausted meanwhile are completed. store is read with CronDispatcher locked, so no job change notified by
+// JobFSM will be missed. A job which can not be scheduled is skipped, so it does not keep other jobs from running.
+func (cd *CronDispatcher) Start(ctx context.Context, store JobStore) error {
 	cd.Lock()
 	defer cd.Unlock()
 
@@ -36,54 +141,172 @@ func (cd *CronDispatcher) Start(ctx context.Context, initJobs []*Job) error {
 		return nil
 	}
 
-	for _, job := range initJobs {
+	scope := &dispatchScope{store: store, running: make(map[string]map[string]context.CancelFunc)}
+	scope.runCtx, scope.cancelRuns = context.WithCancel(context.Background())
+	scope.retryCtx, scope.cancelRetries = context.WithCancel(context.Background())
+	cd.scope = scope
+
+	for _, job := range store.ListJobs() {
 		if err := cd.AddJob(ctx, job); err != nil {
-			return err
+			logs.CtxWarn(logs.CtxAddKVs(ctx, constant.LogJobKey, job.JobKey), "CronDispatcher skipped job: err=%v", err)
+		}
+	}
+
+	busy := make(map[string]bool)
+	for _, run := range store.ListRunningRuns() {
+		busy[run.JobID] = true
+		cd.recoverRun(scope, run)
+	}
+	for _, run := range store.ListPendingRetries() {
+		busy[run.JobID] = true
+		cd.scheduleRetry(scope, run)
+	}
+	now := time.Now()
+	var exhausted []*Job
+	for _, job := range store.ListJobs() {
+		if cd.catchUp(scope, job, now) || busy[job.JobID] {
+			continue
+		}
+		// The final fire may have been dropped by MisfirePolicy, or the previous leader failed to complete the job.
+		if !job.Paused && job.CompleteTime.IsZero() && !cd.firesLate(job) && job.exhausted(now) {
+			exhausted = append(exhausted, job)
 		}
 	}
+	cd.completeJobs(scope, exhausted)
+	cd.collectCompletedJobs(scope)
 
 	cd.Cron.Start()
 	cd.started = true
 
+	logs.CtxInfo(ctx, "CronDispatcher started: entries=%d", len(cd.Cron.Entries()))
 	return nil
 }
 
 // Stop will try to stop CronDispatcher gracefully and will shutdown CronDispatcher forcibly after timeout.
+// Pending retries are abandoned immediately, they will be taken over by the next leader.
 func (cd *CronDispatcher) Stop(ctx context.Context) error {
 	cd.Lock()
-	defer cd.Unlock()
 
 	if !cd.started {
+		cd.Unlock()
 		return nil
 	}
 
 	stop := cd.Cron.Stop()
+	scope := cd.scope
+	scope.cancelRetries()
 	cd.started = false
+	cd.deleteAllJobs()
+
+	cd.Unlock()
+
+	// All tasks are scheduled by Start, cron runs or earlier tasks, so it is safe to wait for them after cron stops.
+	done := make(chan struct{})
+	go func() {
+		<-stop.Done()
+		scope.tasks.Wait()
+		close(done)
+	}()
 
+	// Wait for in-flight runs without holding the lock, so JobFSM will not be blocked.
 	select {
 	case <-ctx.Done():
+		logs.CtxWarn(ctx, "CronDispatcher canceling in-flight runs forcibly: err=%v", ctx.Err())
+		scope.cancelRuns()
+
+		select {
+		case <-done:
+		case <-time.After(dispatcherKillTimeout):
+			logs.CtxError(ctx, "CronDispatcher stopped before canceled runs exited")
+		}
 		return ctx.Err()
-	case <-stop.Done():
+	case <-done:
 	}
+	scope.cancelRuns()
 
+	logs.CtxInfo(ctx, "CronDispatcher stopped")
 	return nil
 }
 
-// AddJob adds a new Job into existing CronDispatcher.
+// Started reports whether CronDispatcher is running.
+func (cd *CronDispatcher) Started() bool {
+	cd.Lock()
+	defer cd.Unlock()
+
+	return cd.started
+}
+
+// DispatcherStatus represents the state of CronDispatcher.
+type DispatcherStatus struct {
+	Started     bool
+	Jobs        int // Jobs scheduled by cron.
+	RunningRuns int // In-flight runs, including triggered and retried runs.
+}
+
+// ToProto converts DispatcherStatus into types.DispatcherStatus.
+func (s *DispatcherStatus) ToProto() *types.DispatcherStatus {
+	return &types.DispatcherStatus{
+		Started:     s.Started,
+		Jobs:        int32(s.Jobs),
+		RunningRuns: int32(s.RunningRuns),
+	}
+}
+
+// Status reads the state of CronDispatcher.
+func (cd *CronDispatcher) Status() *DispatcherStatus {
+	cd.Lock()
+	defer cd.Unlock()
+
+	status := &DispatcherStatus{Started: cd.started}
+	if !cd.started {
+		return status
+	}
+
+	cd.JobEntries.Range(func(key, value interface{}) bool {
+		status.Jobs++
+		return true
+	})
+
+	cd.scope.mu.Lock()
+	for _, runs := range cd.scope.running {
+		status.RunningRuns += len(runs)
+	}
+	cd.scope.mu.Unlock()
+
+	return status
+}
+
+// AddJob adds a new Job into existing CronDispatcher, a paused or completed job is removed from CronDispatcher instead.
+// A one-shot job whose time has passed before it is added fires at once, see firesLate.
 func (cd *CronDispatcher) AddJob(ctx context.Context, job *Job) error {
 	ctx = logs.CtxAddKVs(ctx, constant.LogJobKey, job.JobKey)
 
-	if _, ok := cd.JobEntries.Load(job.JobKey); ok {
+	if _, ok := cd.JobEntries.Load(job.JobID); ok {
 		cd.DeleteJob(ctx, job)
 	}
 
-	entryID, err := cd.Cron.AddJob(job.CronExpression, job)
+	if job.Paused {
+		logs.CtxInfo(ctx, "AddJob skipped paused job")
+		return nil
+	}
+
+	if !job.CompleteTime.IsZero() {
+		logs.CtxInfo(ctx, "AddJob skipped completed job")
+		return nil
+	}
+
+	schedule, err := job.Schedule()
 	if err != nil {
 		logs.CtxError(ctx, "AddJob failed: err=%v", err)
 		return err
 	}
+	if at, ok := job.oneShotTime(); ok && cd.firesLate(job) {
+		schedule = &lateOnceSchedule{at: at}
+	}
 
-	cd.JobEntries.Store(job.JobKey, entryID)
+	entryID := cd.Cron.Schedule(schedule, cd.wrapJob(job))
+
+	cd.JobEntries.Store(job.JobID, &jobEntry{EntryID: entryID, Job: job})
 	logs.CtxInfo(ctx, "AddJob successfully: entryID=%d", entryID)
 	return nil
 }
@@ -92,10 +315,379 @@ func (cd *CronDispatcher) AddJob(ctx context.Context, job *Job) error {
 func (cd *CronDispatcher) DeleteJob(ctx context.Context, job *Job) {
 	ctx = logs.CtxAddKVs(ctx, constant.LogJobKey, job.JobKey)
 
-	if entryID, ok := cd.JobEntries.Load(job.JobKey); ok {
-		cd.Cron.Remove(entryID.(cron.EntryID))
-		cd.JobEntries.Delete(job.JobKey)
+	if value, ok := cd.JobEntries.Load(job.JobID); ok {
+		entry := value.(*jobEntry)
+		cd.Cron.Remove(entry.EntryID)
+		cd.JobEntries.Delete(job.JobID)
+
+		logs.CtxInfo(ctx, "DeleteJob successfully: entryID=%d", entry.EntryID)
+	}
+}
+
+// TriggerJob starts a manual run of the job, ConcurrencyPolicy still applies. The run is returned once it has been
+// recorded, it keeps running in the background.
+func (cd *CronDispatcher) TriggerJob(ctx context.Context, job *Job) (*JobRun, error) {
+	cd.Lock()
+	if !cd.started {
+		cd.Unlock()
+		return nil, ErrDispatcherNotStarted
+	}
+	scope := cd.scope
+	scope.tasks.Add(1)
+	cd.Unlock()
+
+	run := NewJobRun(job, time.Now(), cd.node)
+	run.Trigger = JobRunTriggerManual
+
+	// The run outlives the request, its span joins the request trace as a child.
+	runCtx, ok := cd.begin(scope, job, run, job.ConcurrencyPolicy, trace.SpanContextFromContext(ctx))
+	if !ok {
+		scope.tasks.Done()
+		return run.Clone(), nil
+	}
+
+	logs.CtxInfo(runCtx, "TriggerJob successfully: runID=%s", run.RunID)
+
+	started := run.Clone()
+	go func() {
+		defer scope.tasks.Done()
+		cd.complete(runCtx, scope, job, run)
+	}()
+
+	return started, nil
+}
+
+// firesLate reports whether the one-shot job should fire even if its time has passed, it must be called with lock
+// held. It holds when the job has not fired at its time, unless CronDispatcher is starting and the time was missed
+// during failover, which is left to catchUp and MisfirePolicy. So a one-shot job stored or resumed after its time
+// fires at once.
+func (cd *CronDispatcher) firesLate(job *Job) bool {
+	at, ok := job.oneShotTime()
+	if !ok || !job.LastScheduledTime.Before(at) {
+		return false
+	}
+
+	return cd.started || !at.After(job.UpdateTime)
+}
+
+// lateOnceSchedule fires once at the time like onceSchedule, but it fires at once if the time has passed when cron
+// asks for the first fire time, which happens right after the entry is added or cron is started.
+type lateOnceSchedule struct {
+	at    time.Time
+	asked bool
+}
+
+// Next implements cron.Schedule interface.
+func (s *lateOnceSchedule) Next(t time.Time) time.Time {
+	if s.asked {
+		return time.Time{}
+	}
+	s.asked = true
+
+	if t.Before(s.at) {
+		return s.at
+	}
+	return t
+}
+
+// getJob returns the latest definition of a dispatched job.
+func (cd *CronDispatcher) getJob(jobID string) (*Job, bool) {
+	value, ok := cd.JobEntries.Load(jobID)
+	if !ok {
+		return nil, false
+	}
+
+	return value.(*jobEntry).Job, true
+}
+
+// wrapJob binds the job with the current dispatchScope, overlapping runs are handled by job.ConcurrencyPolicy.
+func (cd *CronDispatcher) wrapJob(job *Job) cron.Job {
+	scope := cd.scope
+
+	return cron.FuncJob(func() {
+		// Schedules fire at whole seconds, so the truncated wake up time is the scheduled time. One-shot jobs may fire
+		// late, they are always scheduled at their time.
+		scheduledTime := time.Now().Truncate(time.Second)
+		if at, ok := job.oneShotTime(); ok {
+			scheduledTime = at
+		}
+		cd.execute(scope, job, NewJobRun(job, scheduledTime, cd.node), job.ConcurrencyPolicy)
+	})
+}
+
+// execute runs a single attempt of the job and records it into run history.
+func (cd *CronDispatcher) execute(scope *dispatchScope, job *Job, run *JobRun, policy ConcurrencyPolicy) {
+	if ctx, ok := cd.begin(scope, job, run, policy, trace.SpanContext{}); ok {
+		cd.complete(ctx, scope, job, run)
+	}
+}
+
+// begin registers the run as in-flight and records its start, the returned context cancels the run. With
+// ConcurrencyPolicyForbid the run is recorded as skipped if the job is still running and false is returned, with
+// ConcurrencyPolicyReplace the running runs are canceled.
+func (cd *CronDispatcher) begin(scope *dispatchScope, job *Job, run *JobRun, policy ConcurrencyPolicy,
+	parent trace.SpanContext) (context.Context, bool) {
+	runCtx, ok := scope.acquire(run, policy)
+	if !ok {
+		ctx := logs.CtxAddKVs(scope.runCtx, constant.LogJobKey, job.JobKey)
+		logs.CtxWarn(ctx, "CronDispatcher skipped run: runID=%s, reason=previous run is still running", run.RunID)
+
+		run.Skip("previous run is still running")
+		observeRunFinished(job, run, false)
+		if err := cd.recorder.RecordJobRun(ctx, run); err == nil {
+			cd.completeIfFinal(ctx, job, run)
+		}
+		return nil, false
+	}
+
+	// Runs fired by schedules start new traces.
+	ctx, _ := tracer.Start(trace.ContextWithSpanContext(runCtx, parent), "JobRun", trace.WithAttributes(
+		attribute.String("crond.job_id", job.JobID),
+		attribute.String("crond.job_key", job.JobKey),
+		attribute.String("crond.namespace", job.Namespace),
+		attribute.String("crond.executor", job.ExecutorType.String()),
+		attribute.String("crond.run_id", run.RunID),
+		attribute.Int("crond.attempt", int(run.Attempt)),
+		attribute.Int("crond.trigger", int(run.Trigger)),
+	))
+	ctx = withTraceKVs(logs.CtxAddKVs(ctx, constant.LogJobKey, job.JobKey))
+	observeRunStarted(job, run)
+	_ = cd.recorder.RecordJobRun(ctx, run)
+	return ctx, true
+}
+
+// complete executes the begun run and finishes it.
+func (cd *CronDispatcher) complete(ctx context.Context, scope *dispatchScope, job *Job, run *JobRun) {
+	defer scope.release(run)
+
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	result, err := job.Execute(WithScheduledTime(ctx, run.ScheduledTime))
+	run.Finish(result, err)
+	observeRunFinished(job, run, true)
+
+	span.SetAttributes(attribute.String("crond.status", run.Status.String()))
+	if run.Status != JobRunStatusSucceeded {
+		span.SetStatus(codes.Error, run.Error)
+	}
+
+	// The run context may have been canceled, history should still be recorded in the same trace.
+	ctx = withTraceKVs(logs.CtxAddKVs(trace.ContextWithSpan(context.Background(), span), constant.LogJobKey, job.JobKey))
+	cd.finish(ctx, scope, job, run)
+}
+
+// finish records the finished run and schedules the next attempt if RetryPolicy allows. If the run can not be
+// recorded, this node must have lost leadership, the next leader will take over it as an interrupted run. ctx must
+// outlive the run context, since the run may have been canceled.
+func (cd *CronDispatcher) finish(ctx context.Context, scope *dispatchScope, job *Job, run *JobRun) {
+	if job.RetryPolicy.ShouldRetry(run) {
+		run.NextRetryTime = time.Now().Add(job.RetryPolicy.Backoff(run.Attempt))
+	}
+
+	if err := cd.recorder.RecordJobRun(ctx, run); err != nil {
+		return
+	}
+
+	if !run.NextRetryTime.IsZero() {
+		cd.scheduleRetry(scope, run)
+		return
+	}
+	cd.completeIfFinal(ctx, job, run)
+}
+
+// completeIfFinal completes the job after the run of its final fire has finished without retry, manual runs never
+// complete jobs.
+func (cd *CronDispatcher) completeIfFinal(ctx context.Context, job *Job, run *JobRun) {
+	if run.Trigger == JobRunTriggerManual || !job.exhausted(run.ScheduledTime) {
+		return
+	}
+
+	_ = cd.completer.CompleteJob(ctx, job.JobID)
+}
+
+// completeJobs completes the jobs in the background, so that it can be called with lock held.
+func (cd *CronDispatcher) completeJobs(scope *dispatchScope, jobs []*Job) {
+	if len(jobs) == 0 {
+		return
+	}
+
+	scope.tasks.Add(1)
+	go func() {
+		defer scope.tasks.Done()
+
+		for _, job := range jobs {
+			_ = cd.completer.CompleteJob(logs.CtxAddKVs(context.Background(), constant.LogJobKey, job.JobKey), job.JobID)
+		}
+	}()
+}
+
+// collectCompletedJobs deletes completed jobs once completedJobTTL has elapsed, until CronDispatcher stops.
+func (cd *CronDispatcher) collectCompletedJobs(scope *dispatchScope) {
+	scope.tasks.Add(1)
+	go func() {
+		defer scope.tasks.Done()
+
+		ticker := time.NewTicker(completedJobGCInterval)
+		defer ticker.Stop()
+
+		for {
+			for _, job := range scope.store.ListJobs() {
+				if job.CompleteTime.IsZero() || time.Since(job.CompleteTime) < cd.completedJobTTL {
+					continue
+				}
+
+				ctx := logs.CtxAddKVs(context.Background(), constant.LogJobKey, job.JobKey)
+				if _, err := cd.completer.DeleteJob(ctx, job.JobID); err == nil {
+					logs.CtxInfo(ctx, "CronDispatcher collected completed job: jobID=%s, completeTime=%v", job.JobID,
+						job.CompleteTime)
+				}
+			}
+
+			select {
+			case <-scope.retryCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// scheduleRetry retries the failed run at its NextRetryTime with the latest job definition.
+func (cd *CronDispatcher) scheduleRetry(scope *dispatchScope, prev *JobRun) {
+	scope.tasks.Add(1)
+
+	go func() {
+		defer scope.tasks.Done()
+
+		timer := time.NewTimer(time.Until(prev.NextRetryTime))
+		defer timer.Stop()
+
+		select {
+		case <-scope.retryCtx.Done():
+			return
+		case <-timer.C:
+		}
+
+		job, ok := cd.getJob(prev.JobID)
+		if !ok {
+			return
+		}
+
+		logs.CtxInfo(logs.CtxAddKVs(scope.runCtx, constant.LogJobKey, job.JobKey),
+			"CronDispatcher retrying run: runID=%s, attempt=%d", prev.RunID, prev.Attempt+1)
+		// A retry continues the failed run, so it is never skipped or replacing others.
+		cd.execute(scope, job, NewRetryJobRun(prev, cd.node), ConcurrencyPolicyAllow)
+	}()
+}
+
+// recoverRun finishes a run interrupted by the previous leader, it may be retried according to RetryPolicy unless
+// the job is paused.
+func (cd *CronDispatcher) recoverRun(scope *dispatchScope, run *JobRun) {
+	job, ok := cd.getJob(run.JobID)
+	run.Finish(nil, errRunInterrupted)
+
+	scope.tasks.Add(1)
+	go func() {
+		defer scope.tasks.Done()
+
+		if !ok {
+			_ = cd.recorder.RecordJobRun(context.Background(), run)
+			return
+		}
+		observeRunFinished(job, run, false)
+		cd.finish(logs.CtxAddKVs(context.Background(), constant.LogJobKey, job.JobKey), scope, job, run)
+	}()
+}
+
+// catchUp fires the job for the fires missed since its last scheduled run or update, sequentially in the background.
+// It reports whether any fire is caught up.
+func (cd *CronDispatcher) catchUp(scope *dispatchScope, job *Job, now time.Time) bool {
+	if job.Paused || !job.CompleteTime.IsZero() {
+		return false
+	}
+
+	last := job.LastScheduledTime
+	if job.UpdateTime.After(last) {
+		last = job.UpdateTime
+	}
+
+	schedule, err := job.Schedule()
+	if err != nil {
+		return false
+	}
+
+	fires := job.MisfirePolicy.Misfires(schedule, last, now)
+	if len(fires) == 0 {
+		return false
+	}
+
+	ctx := logs.CtxAddKVs(scope.runCtx, constant.LogJobKey, job.JobKey)
+	logs.CtxInfo(ctx, "CronDispatcher catching up misfires: last=%v, fires=%d", last, len(fires))
+
+	scope.tasks.Add(1)
+	go func() {
+		defer scope.tasks.Done()
+
+		for _, fire := range fires {
+			if scope.retryCtx.Err() != nil {
+				return
+			}
+
+			job, ok := cd.getJob(job.JobID)
+			if !ok {
+				return
+			}
+
+			run := NewJobRun(job, fire, cd.node)
+			run.Trigger = JobRunTriggerMisfire
+			cd.execute(scope, job, run, job.ConcurrencyPolicy)
+		}
+	}()
+
+	return true
+}
+
+func (cd *CronDispatcher) deleteAllJobs() {
+	cd.JobEntries.Range(func(jobID, value interface{}) bool {
+		cd.Cron.Remove(value.(*jobEntry).EntryID)
+		cd.JobEntries.Delete(jobID)
+		return true
+	})
+}
+
+// OnJobSet implements JobFSMListener interface, it only takes effect while CronDispatcher is running.
+func (cd *CronDispatcher) OnJobSet(job *Job) {
+	cd.Lock()
+	defer cd.Unlock()
+
+	if cd.started {
+		_ = cd.AddJob(context.Background(), job)
+	}
+}
+
+// OnJobDeleted implements JobFSMListener interface, it only takes effect while CronDispatcher is running.
+func (cd *CronDispatcher) OnJobDeleted(job *Job) {
+	cd.Lock()
+	defer cd.Unlock()
+
+	if cd.started {
+		cd.DeleteJob(context.Background(), job)
+	}
+}
+
+// OnRestored implements JobFSMListener interface, it reloads all jobs while CronDispatcher is running.
+func (cd *CronDispatcher) OnRestored(jobs []*Job) {
+	cd.Lock()
+	defer cd.Unlock()
+
+	if !cd.started {
+		return
+	}
 
-		logs.CtxInfo(ctx, "DeleteJob successfully: entryID=%d", entryID)
+	ctx := context.Background()
+	cd.deleteAllJobs()
+	for _, job := range jobs {
+		_ = cd.AddJob(ctx, job)
 	}
 }
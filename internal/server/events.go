@@ -0,0 +1,239 @@
+package server
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/KevinWu0904/crond/proto/types"
+)
+
+// watcherBufferSize is the number of live events buffered for each watcher, a watcher falling behind further is
+// closed with ErrWatcherTooSlow instead of blocking raft apply goroutine.
+const watcherBufferSize = 256
+
+// ErrRevisionCompacted throws when watching from a revision whose following events are no longer kept.
+var ErrRevisionCompacted = errors.New("revision has been compacted")
+
+// ErrWatcherTooSlow throws when a watcher does not consume events as fast as they are applied.
+var ErrWatcherTooSlow = errors.New("watcher is too slow")
+
+// ErrWatchClosed throws when EventHub has been closed by server shutdown.
+var ErrWatchClosed = errors.New("watch closed")
+
+// JobEventType defines the kinds of changes emitted by EventHub.
+type JobEventType int8
+
+const (
+	// JobEventTypeUnspecified is the zero value of JobEventType.
+	JobEventTypeUnspecified JobEventType = iota
+	// JobEventTypeJobCreated is emitted when a job is created.
+	JobEventTypeJobCreated
+	// JobEventTypeJobUpdated is emitted when a job is updated, paused or resumed.
+	JobEventTypeJobUpdated
+	// JobEventTypeJobDeleted is emitted when a job is deleted.
+	JobEventTypeJobDeleted
+	// JobEventTypeRunStarted is emitted when a run starts.
+	JobEventTypeRunStarted
+	// JobEventTypeRunFinished is emitted when a run finishes, including runs skipped without starting.
+	JobEventTypeRunFinished
+	// JobEventTypeJobCompleted is emitted when a job is completed after its final run.
+	JobEventTypeJobCompleted
+)
+
+var jobEventTypeNames = map[JobEventType]string{
+	JobEventTypeUnspecified:  "unspecified",
+	JobEventTypeJobCreated:   "job_created",
+	JobEventTypeJobUpdated:   "job_updated",
+	JobEventTypeJobDeleted:   "job_deleted",
+	JobEventTypeRunStarted:   "run_started",
+	JobEventTypeRunFinished:  "run_finished",
+	JobEventTypeJobCompleted: "job_completed",
+}
+
+// String implements fmt.Stringer interface.
+func (t JobEventType) String() string {
+	return jobEventTypeNames[t]
+}
+
+// IsRunEvent reports whether the event is about a run.
+func (t JobEventType) IsRunEvent() bool {
+	return t == JobEventTypeRunStarted || t == JobEventTypeRunFinished
+}
+
+// JobEvent represents a change applied by JobFSM. Revision is the raft log index of the change, so it is the same on
+// all nodes, events applied by the same log share the same Revision. Run events also carry the job of the run.
+type JobEvent struct {
+	Revision uint64
+	Type     JobEventType
+	Job      *Job
+	Run      *JobRun
+}
+
+// ToProto converts JobEvent into types.WatchEvent.
+func (e *JobEvent) ToProto() *types.WatchEvent {
+	pb := &types.WatchEvent{
+		Revision: e.Revision,
+		Type:     types.WatchEventType(e.Type),
+	}
+	if e.Job != nil {
+		pb.Job = e.Job.ToProto()
+	}
+	if e.Run != nil {
+		pb.Run = e.Run.ToProto()
+	}
+
+	return pb
+}
+
+// EventHub fans out JobEvents to watchers. It keeps at most historyLimit latest events, so watchers can resume from
+// a recent revision.
+type EventHub struct {
+	sync.Mutex
+
+	history      []*JobEvent
+	historyLimit int
+	compacted    uint64 // Events up to this revision may have been dropped.
+	watchers     map[*Watcher]struct{}
+	closed       bool
+}
+
+// NewEventHub creates EventHub, it keeps at most historyLimit events for resuming.
+func NewEventHub(historyLimit int) *EventHub {
+	return &EventHub{
+		historyLimit: historyLimit,
+		watchers:     make(map[*Watcher]struct{}),
+	}
+}
+
+// Publish appends events to history and delivers them to matching watchers, it never blocks.
+func (h *EventHub) Publish(events ...*JobEvent) {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	h.history = append(h.history, events...)
+	if drop := len(h.history) - h.historyLimit; drop > 0 {
+		h.compacted = h.history[drop-1].Revision
+		h.history = append([]*JobEvent(nil), h.history[drop:]...)
+	}
+
+	for w := range h.watchers {
+		for _, event := range events {
+			if !w.filter(event) {
+				continue
+			}
+
+			select {
+			case w.ch <- event:
+			default:
+				h.stop(w, ErrWatcherTooSlow)
+			}
+			if w.err != nil {
+				break
+			}
+		}
+	}
+}
+
+// Watch creates a Watcher receiving events selected by filter. Events after the revision are replayed from history
+// first, zero revision only receives events from now on. It fails with ErrRevisionCompacted if any event after the
+// revision is no longer kept.
+func (h *EventHub) Watch(revision uint64, filter func(event *JobEvent) bool) (*Watcher, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.closed {
+		return nil, ErrWatchClosed
+	}
+
+	var replay []*JobEvent
+	if revision != 0 {
+		if revision < h.compacted {
+			return nil, ErrRevisionCompacted
+		}
+		for _, event := range h.history {
+			if event.Revision > revision && filter(event) {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	w := &Watcher{
+		hub:    h,
+		filter: filter,
+		ch:     make(chan *JobEvent, len(replay)+watcherBufferSize),
+	}
+	for _, event := range replay {
+		w.ch <- event
+	}
+	h.watchers[w] = struct{}{}
+
+	return w, nil
+}
+
+// Reset drops history when JobFSM is restored from a snapshot at the revision, watchers are closed with
+// ErrRevisionCompacted since the events between their positions and the snapshot are lost.
+func (h *EventHub) Reset(revision uint64) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.history = nil
+	h.compacted = revision
+	for w := range h.watchers {
+		h.stop(w, ErrRevisionCompacted)
+	}
+}
+
+// Close closes all watchers with ErrWatchClosed and rejects new watchers.
+func (h *EventHub) Close() {
+	h.Lock()
+	defer h.Unlock()
+
+	h.closed = true
+	for w := range h.watchers {
+		h.stop(w, ErrWatchClosed)
+	}
+}
+
+// stop removes the watcher and closes its channel, it must be called with lock held.
+func (h *EventHub) stop(w *Watcher, err error) {
+	if _, ok := h.watchers[w]; !ok {
+		return
+	}
+
+	delete(h.watchers, w)
+	w.err = err
+	close(w.ch)
+}
+
+// Watcher receives JobEvents from EventHub.
+type Watcher struct {
+	hub    *EventHub
+	filter func(event *JobEvent) bool
+	ch     chan *JobEvent
+	err    error
+}
+
+// Events returns the channel of events, it is closed when the watcher stops, Err tells the reason then.
+func (w *Watcher) Events() <-chan *JobEvent {
+	return w.ch
+}
+
+// Err returns the reason why the watcher stopped, it is nil if the watcher was closed by Close.
+func (w *Watcher) Err() error {
+	w.hub.Lock()
+	defer w.hub.Unlock()
+
+	return w.err
+}
+
+// Close stops the watcher.
+func (w *Watcher) Close() {
+	w.hub.Lock()
+	defer w.hub.Unlock()
+
+	w.hub.stop(w, nil)
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEventHubWatch(t *testing.T) {
+	all := func(event *JobEvent) bool { return true }
+	even := func(event *JobEvent) bool { return event.Revision%2 == 0 }
+
+	tests := []struct {
+		name         string
+		historyLimit int
+		published    uint64
+		revision     uint64
+		filter       func(event *JobEvent) bool
+		wantErr      error
+		want         []uint64
+	}{
+		{name: "from now on", historyLimit: 10, published: 5, revision: 0, filter: all, want: []uint64{6, 7}},
+		{name: "replay history", historyLimit: 10, published: 5, revision: 2, filter: all, want: []uint64{3, 4, 5, 6, 7}},
+		{name: "replay nothing", historyLimit: 10, published: 5, revision: 5, filter: all, want: []uint64{6, 7}},
+		{name: "replay filtered", historyLimit: 10, published: 5, revision: 1, filter: even, want: []uint64{2, 4, 6}},
+		{name: "replay from compacted", historyLimit: 3, published: 5, revision: 2, filter: all, want: []uint64{3, 4, 5, 6, 7}},
+		{name: "revision compacted", historyLimit: 3, published: 5, revision: 1, filter: all, wantErr: ErrRevisionCompacted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewEventHub(tt.historyLimit)
+			publishEvents(hub, 1, tt.published)
+
+			w, err := hub.Watch(tt.revision, tt.filter)
+			if err != tt.wantErr {
+				t.Fatalf("Watch() err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer w.Close()
+
+			publishEvents(hub, tt.published+1, tt.published+2)
+			if got := receiveRevisions(w); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("received revisions %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventHubStopsWatchers(t *testing.T) {
+	tests := []struct {
+		name    string
+		stop    func(hub *EventHub, w *Watcher)
+		want    []uint64
+		wantErr error
+	}{
+		{
+			name:    "watcher too slow",
+			stop:    func(hub *EventHub, w *Watcher) { publishEvents(hub, 2, watcherBufferSize+2) },
+			want:    revisionRange(2, watcherBufferSize+1),
+			wantErr: ErrWatcherTooSlow,
+		},
+		{
+			name:    "hub closed",
+			stop:    func(hub *EventHub, w *Watcher) { hub.Close() },
+			wantErr: ErrWatchClosed,
+		},
+		{
+			name:    "hub reset",
+			stop:    func(hub *EventHub, w *Watcher) { hub.Reset(10) },
+			wantErr: ErrRevisionCompacted,
+		},
+		{
+			name: "watcher closed",
+			stop: func(hub *EventHub, w *Watcher) { w.Close() },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewEventHub(10)
+			publishEvents(hub, 1, 1)
+
+			w, err := hub.Watch(1, func(event *JobEvent) bool { return true })
+			if err != nil {
+				t.Fatalf("Watch failed: err=%v", err)
+			}
+
+			tt.stop(hub, w)
+			got := receiveRevisions(w)
+			if _, ok := <-w.Events(); ok {
+				t.Fatalf("events channel is still open")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("received revisions %v, want %v", got, tt.want)
+			}
+			if err := w.Err(); err != tt.wantErr {
+				t.Errorf("Err() = %v, want %v", err, tt.wantErr)
+			}
+
+			// Stopped watchers no longer receive events.
+			publishEvents(hub, 100, 100)
+		})
+	}
+}
+
+func TestEventHubWatchAfterClose(t *testing.T) {
+	hub := NewEventHub(10)
+	hub.Close()
+
+	if _, err := hub.Watch(0, func(event *JobEvent) bool { return true }); err != ErrWatchClosed {
+		t.Errorf("Watch() err = %v, want %v", err, ErrWatchClosed)
+	}
+}
+
+// publishEvents publishes a job event for each revision in [from, to].
+func publishEvents(hub *EventHub, from, to uint64) {
+	for revision := from; revision <= to; revision++ {
+		hub.Publish(&JobEvent{Revision: revision, Type: JobEventTypeJobUpdated})
+	}
+}
+
+// receiveRevisions returns the revisions of events already delivered to the watcher without blocking.
+func receiveRevisions(w *Watcher) []uint64 {
+	var revisions []uint64
+	for {
+		select {
+		case event, ok := <-w.Events():
+			if !ok {
+				return revisions
+			}
+			revisions = append(revisions, event.Revision)
+		default:
+			return revisions
+		}
+	}
+}
+
+func revisionRange(from, to uint64) []uint64 {
+	var revisions []uint64
+	for revision := from; revision <= to; revision++ {
+		revisions = append(revisions, revision)
+	}
+
+	return revisions
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrExecutorNotFound throws when no Executor has been registered for the ExecutorType.
+var ErrExecutorNotFound = errors.New("executor not found")
+
+// ExecutorType defines multiple executor types, different type will be running by different executors.
+type ExecutorType int8
+
+const (
+	// ExecutorTypeUnspecified means the job has not chosen any executor yet.
+	ExecutorTypeUnspecified ExecutorType = iota
+	// ExecutorTypeShell runs a local command, see ShellExecutor.
+	ExecutorTypeShell
+	// ExecutorTypeHTTP calls an HTTP endpoint, see HTTPExecutor.
+	ExecutorTypeHTTP
+	// ExecutorTypeGRPC invokes a unary gRPC method, see GRPCExecutor.
+	ExecutorTypeGRPC
+)
+
+var executorTypeNames = map[ExecutorType]string{
+	ExecutorTypeUnspecified: "unspecified",
+	ExecutorTypeShell:       "shell",
+	ExecutorTypeHTTP:        "http",
+	ExecutorTypeGRPC:        "grpc",
+}
+
+// String implements fmt.Stringer interface.
+func (t ExecutorType) String() string {
+	return executorTypeNames[t]
+}
+
+// Executor runs a job with its own typed configuration carried by Job.
+type Executor interface {
+	// Validate checks the executor specific configuration of the job.
+	Validate(job *Job) error
+	// Execute runs the job once, it must return as soon as possible once ctx is done.
+	Execute(ctx context.Context, job *Job) (*ExecutionResult, error)
+}
+
+// ExecutionResult represents the outcome of a single job execution.
+type ExecutionResult struct {
+	Code   int32  // Exit code, HTTP status code or gRPC status code depends on ExecutorType.
+	Output string // Captured output, executors should keep it small.
+}
+
+type scheduledTimeKey struct{}
+
+// WithScheduledTime attaches the time when the run was scheduled to fire.
+func WithScheduledTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, scheduledTimeKey{}, t)
+}
+
+// ScheduledTimeFromContext returns the time when the run was scheduled to fire, it defaults to now.
+func ScheduledTimeFromContext(ctx context.Context) time.Time {
+	if t, ok := ctx.Value(scheduledTimeKey{}).(time.Time); ok {
+		return t
+	}
+
+	return time.Now()
+}
+
+var executors = struct {
+	sync.RWMutex
+	m map[ExecutorType]Executor
+}{
+	m: make(map[ExecutorType]Executor),
+}
+
+// RegisterExecutor registers an Executor for the ExecutorType, it is expected to be called in init functions.
+func RegisterExecutor(t ExecutorType, executor Executor) {
+	executors.Lock()
+	defer executors.Unlock()
+
+	if _, ok := executors.m[t]; ok {
+		panic(fmt.Sprintf("RegisterExecutor called twice: type=%d", t))
+	}
+	executors.m[t] = executor
+}
+
+// GetExecutor returns the registered Executor of the ExecutorType.
+func GetExecutor(t ExecutorType) (Executor, error) {
+	executors.RLock()
+	defer executors.RUnlock()
+
+	executor, ok := executors.m[t]
+	if !ok {
+		return nil, fmt.Errorf("%w: type=%d", ErrExecutorNotFound, t)
+	}
+
+	return executor, nil
+}
+
+// cappedBuffer keeps at most limit bytes and silently discards the rest, it protects run history from huge outputs.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// newCappedBuffer creates cappedBuffer.
+func newCappedBuffer(limit int) *cappedBuffer {
+	return &cappedBuffer{
+		limit: limit,
+	}
+}
+
+// Write implements io.Writer interface, it never fails so that the producer will not be blocked.
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if remain := b.limit - b.buf.Len(); remain < len(p) {
+		b.buf.Write(p[:remain])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+
+	return len(p), nil
+}
+
+// String returns the captured content, a marker is appended if content has been truncated.
+func (b *cappedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "...(truncated)"
+	}
+
+	return b.buf.String()
+}
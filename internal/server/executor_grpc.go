@@ -0,0 +1,324 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KevinWu0904/crond/proto/types"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	// grpcMaxResponseSize is the maximum bytes of captured JSON-encoded response for a single run.
+	grpcMaxResponseSize = 64 * 1024
+	// grpcDefaultTimeout bounds a run whose job has no Timeout, so a stalled server will not hold the run forever.
+	grpcDefaultTimeout = time.Minute * 5
+)
+
+// GRPCConfig stores GRPCExecutor specific configurations of a job.
+// FileDescriptorSet is a serialized google.protobuf.FileDescriptorSet, server reflection is used if it is empty.
+type GRPCConfig struct {
+	Target            string            `json:"target"`
+	Method            string            `json:"method"`
+	RequestJSON       string            `json:"request_json,omitempty"`
+	FileDescriptorSet []byte            `json:"file_descriptor_set,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	UseTLS            bool              `json:"use_tls,omitempty"`
+}
+
+// NewGRPCConfigFromProto converts types.GRPCExecutorConfig into GRPCConfig.
+func NewGRPCConfigFromProto(pb *types.GRPCExecutorConfig) *GRPCConfig {
+	if pb == nil {
+		return nil
+	}
+
+	return &GRPCConfig{
+		Target:            pb.GetTarget(),
+		Method:            pb.GetMethod(),
+		RequestJSON:       pb.GetRequestJson(),
+		FileDescriptorSet: pb.GetFileDescriptorSet(),
+		Metadata:          pb.GetMetadata(),
+		UseTLS:            pb.GetUseTls(),
+	}
+}
+
+// ToProto converts GRPCConfig into types.GRPCExecutorConfig.
+func (c *GRPCConfig) ToProto() *types.GRPCExecutorConfig {
+	return &types.GRPCExecutorConfig{
+		Target:            c.Target,
+		Method:            c.Method,
+		RequestJson:       c.RequestJSON,
+		FileDescriptorSet: c.FileDescriptorSet,
+		Metadata:          c.Metadata,
+		UseTls:            c.UseTLS,
+	}
+}
+
+// Clone returns a deep copy of GRPCConfig.
+func (c *GRPCConfig) Clone() *GRPCConfig {
+	clone := *c
+	clone.FileDescriptorSet = append([]byte(nil), c.FileDescriptorSet...)
+	clone.Metadata = make(map[string]string, len(c.Metadata))
+	for k, v := range c.Metadata {
+		clone.Metadata[k] = v
+	}
+
+	return &clone
+}
+
+// splitMethod splits the full method name, it accepts "/pkg.Service/Method", "pkg.Service/Method" and
+// "pkg.Service.Method".
+func (c *GRPCConfig) splitMethod() (protoreflect.FullName, protoreflect.Name, error) {
+	name := strings.TrimPrefix(c.Method, "/")
+
+	i := strings.LastIndexAny(name, "/.")
+	if i <= 0 || i == len(name)-1 {
+		return "", "", fmt.Errorf("grpc method %q is malformed", c.Method)
+	}
+
+	service, method := protoreflect.FullName(name[:i]), protoreflect.Name(name[i+1:])
+	if !service.IsValid() || !method.IsValid() {
+		return "", "", fmt.Errorf("grpc method %q is malformed", c.Method)
+	}
+
+	return service, method, nil
+}
+
+// GRPCExecutor invokes a unary gRPC method with a JSON-encoded request, message types are resolved from the
+// provided FileDescriptorSet or server reflection.
+type GRPCExecutor struct{}
+
+func init() {
+	RegisterExecutor(ExecutorTypeGRPC, &GRPCExecutor{})
+}
+
+// Validate implements Executor interface, the request is fully checked if FileDescriptorSet is provided.
+func (*GRPCExecutor) Validate(job *Job) error {
+	c := job.GRPC
+	if c == nil {
+		return errors.New("grpc config is required")
+	}
+
+	if c.Target == "" {
+		return errors.New("grpc target is required")
+	}
+
+	service, method, err := c.splitMethod()
+	if err != nil {
+		return err
+	}
+
+	if len(c.FileDescriptorSet) == 0 {
+		return nil
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(c.FileDescriptorSet, &set); err != nil {
+		return fmt.Errorf("grpc file_descriptor_set is malformed: %v", err)
+	}
+
+	md, err := findMethod(&set, service, method)
+	if err != nil {
+		return err
+	}
+
+	if err := protojson.Unmarshal([]byte(c.requestJSON()), dynamicpb.NewMessage(md.Input())); err != nil {
+		return fmt.Errorf("grpc request_json is malformed: %v", err)
+	}
+
+	return nil
+}
+
+// Execute implements Executor interface, Code of the result is the gRPC status code. Reflection and the call are
+// bounded by the job Timeout, or grpcDefaultTimeout if the job has none.
+func (*GRPCExecutor) Execute(ctx context.Context, job *Job) (*ExecutionResult, error) {
+	c := job.GRPC
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, grpcDefaultTimeout)
+		defer cancel()
+	}
+
+	service, method, err := c.splitMethod()
+	if err != nil {
+		return nil, err
+	}
+
+	creds := grpc.WithInsecure()
+	if c.UseTLS {
+		creds = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12}))
+	}
+
+	// The callee joins the trace of the run through W3C traceparent metadata.
+	conn, err := grpc.DialContext(ctx, c.Target, creds, grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if len(c.FileDescriptorSet) > 0 {
+		err = proto.Unmarshal(c.FileDescriptorSet, set)
+	} else {
+		set, err = resolveByReflection(ctx, conn, service)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := findMethod(set, service, method)
+	if err != nil {
+		return nil, err
+	}
+
+	req := dynamicpb.NewMessage(md.Input())
+	if err := protojson.Unmarshal([]byte(c.requestJSON()), req); err != nil {
+		return nil, err
+	}
+	resp := dynamicpb.NewMessage(md.Output())
+
+	for k, v := range c.Metadata {
+		ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+	}
+
+	err = conn.Invoke(ctx, fmt.Sprintf("/%s/%s", service, method), req, resp)
+	result := &ExecutionResult{
+		Code: int32(status.Code(err)),
+	}
+	if err != nil {
+		result.Output = status.Convert(err).Message()
+		return result, err
+	}
+
+	data, err := protojson.Marshal(resp)
+	if err != nil {
+		return result, err
+	}
+
+	output := newCappedBuffer(grpcMaxResponseSize)
+	_, _ = output.Write(data)
+	result.Output = output.String()
+
+	return result, nil
+}
+
+func (c *GRPCConfig) requestJSON() string {
+	if c.RequestJSON == "" {
+		return "{}"
+	}
+
+	return c.RequestJSON
+}
+
+// findMethod builds descriptors from the FileDescriptorSet and searches the method.
+func findMethod(set *descriptorpb.FileDescriptorSet, service protoreflect.FullName,
+	method protoreflect.Name) (protoreflect.MethodDescriptor, error) {
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("grpc file_descriptor_set is malformed: %v", err)
+	}
+
+	d, err := files.FindDescriptorByName(service)
+	if err != nil {
+		return nil, fmt.Errorf("grpc service %q not found: %v", service, err)
+	}
+
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("grpc service %q is not a service", service)
+	}
+
+	md := sd.Methods().ByName(method)
+	if md == nil {
+		return nil, fmt.Errorf("grpc method %q not found in service %q", method, service)
+	}
+
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("grpc method %q is not unary", method)
+	}
+
+	return md, nil
+}
+
+// resolveByReflection fetches the file containing the service and all its dependencies by server reflection.
+func resolveByReflection(ctx context.Context, conn *grpc.ClientConn,
+	service protoreflect.FullName) (*descriptorpb.FileDescriptorSet, error) {
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	set := &descriptorpb.FileDescriptorSet{}
+
+	pending := []*rpb.ServerReflectionRequest{{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: string(service)},
+	}}
+	for len(pending) > 0 {
+		req := pending[0]
+		pending = pending[1:]
+
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return nil, fmt.Errorf("grpc server reflection failed: code=%d, message=%s",
+				errResp.GetErrorCode(), errResp.GetErrorMessage())
+		}
+
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			var fd descriptorpb.FileDescriptorProto
+			if err := proto.Unmarshal(raw, &fd); err != nil {
+				return nil, err
+			}
+			if _, ok := files[fd.GetName()]; ok {
+				continue
+			}
+			files[fd.GetName()] = &fd
+			set.File = append(set.File, &fd)
+
+			for _, dep := range fd.GetDependency() {
+				if _, ok := files[dep]; ok {
+					continue
+				}
+
+				// Well-known types are usually linked into crond, no need to ask the server.
+				if global, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+					gfd := protodesc.ToFileDescriptorProto(global)
+					files[dep] = gfd
+					set.File = append(set.File, gfd)
+					continue
+				}
+
+				pending = append(pending, &rpb.ServerReflectionRequest{
+					MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+				})
+			}
+		}
+	}
+
+	return set, nil
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/KevinWu0904/crond/proto/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	// httpMaxResponseSize is the maximum bytes of captured response body for a single run, the rest is not read.
+	httpMaxResponseSize = 64 * 1024
+	// httpDefaultTimeout bounds a run whose job has no Timeout, so a hanging endpoint will not hold the run forever.
+	httpDefaultTimeout = time.Minute * 5
+)
+
+// httpMethods are the methods HTTPConfig.Method accepts, CONNECT and TRACE are left out since they do not request a
+// resource.
+var httpMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// HTTPStatusCodeRange represents an inclusive HTTP status code range.
+type HTTPStatusCodeRange struct {
+	Min int32 `json:"min"`
+	Max int32 `json:"max"`
+}
+
+// HTTPConfig stores HTTPExecutor specific configurations of a job.
+type HTTPConfig struct {
+	Method             string                `json:"method,omitempty"`
+	URL                string                `json:"url"`
+	Headers            map[string]string     `json:"headers,omitempty"`
+	BodyTemplate       string                `json:"body_template,omitempty"`
+	SuccessStatusCodes []HTTPStatusCodeRange `json:"success_status_codes,omitempty"`
+	SuccessBodyRegex   string                `json:"success_body_regex,omitempty"`
+}
+
+// NewHTTPConfigFromProto converts types.HTTPExecutorConfig into HTTPConfig.
+func NewHTTPConfigFromProto(pb *types.HTTPExecutorConfig) *HTTPConfig {
+	if pb == nil {
+		return nil
+	}
+
+	c := &HTTPConfig{
+		Method:           pb.GetMethod(),
+		URL:              pb.GetUrl(),
+		Headers:          pb.GetHeaders(),
+		BodyTemplate:     pb.GetBodyTemplate(),
+		SuccessBodyRegex: pb.GetSuccessBodyRegex(),
+	}
+	for _, r := range pb.GetSuccessStatusCodes() {
+		c.SuccessStatusCodes = append(c.SuccessStatusCodes, HTTPStatusCodeRange{Min: r.GetMin(), Max: r.GetMax()})
+	}
+
+	return c
+}
+
+// ToProto converts HTTPConfig into types.HTTPExecutorConfig.
+func (c *HTTPConfig) ToProto() *types.HTTPExecutorConfig {
+	pb := &types.HTTPExecutorConfig{
+		Method:           c.Method,
+		Url:              c.URL,
+		Headers:          c.Headers,
+		BodyTemplate:     c.BodyTemplate,
+		SuccessBodyRegex: c.SuccessBodyRegex,
+	}
+	for _, r := range c.SuccessStatusCodes {
+		pb.SuccessStatusCodes = append(pb.SuccessStatusCodes, &types.HTTPStatusCodeRange{Min: r.Min, Max: r.Max})
+	}
+
+	return pb
+}
+
+// Clone returns a deep copy of HTTPConfig.
+func (c *HTTPConfig) Clone() *HTTPConfig {
+	clone := *c
+	clone.SuccessStatusCodes = append([]HTTPStatusCodeRange(nil), c.SuccessStatusCodes...)
+	clone.Headers = make(map[string]string, len(c.Headers))
+	for k, v := range c.Headers {
+		clone.Headers[k] = v
+	}
+
+	return &clone
+}
+
+// method returns the HTTP method, it defaults to POST if body template is provided, otherwise GET.
+func (c *HTTPConfig) method() string {
+	if c.Method != "" {
+		return strings.ToUpper(c.Method)
+	}
+
+	if c.BodyTemplate != "" {
+		return http.MethodPost
+	}
+
+	return http.MethodGet
+}
+
+// isSuccessStatus reports whether the status code is successful, it defaults to 2xx if no range is configured.
+func (c *HTTPConfig) isSuccessStatus(code int) bool {
+	if len(c.SuccessStatusCodes) == 0 {
+		return code >= http.StatusOK && code < http.StatusMultipleChoices
+	}
+
+	for _, r := range c.SuccessStatusCodes {
+		if int32(code) >= r.Min && int32(code) <= r.Max {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HTTPTemplateData represents variables which can be referenced by HTTPConfig.BodyTemplate,
+// e.g. {"job": "{{.JobKey}}", "scheduled_at": {{.ScheduledTime.Unix}}}.
+type HTTPTemplateData struct {
+	JobID          string
+	Namespace      string
+	JobKey         string
+	JobDisplayName string
+	Labels         map[string]string
+	ScheduledTime  time.Time
+}
+
+// HTTPExecutor calls an HTTP endpoint, the run succeeds if both the status code and the body match success rules.
+type HTTPExecutor struct {
+	client *http.Client
+}
+
+func init() {
+	RegisterExecutor(ExecutorTypeHTTP, &HTTPExecutor{client: &http.Client{}})
+}
+
+// Validate implements Executor interface.
+func (*HTTPExecutor) Validate(job *Job) error {
+	c := job.HTTP
+	if c == nil {
+		return errors.New("http config is required")
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("http url is malformed: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("http url scheme %q is not supported", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("http url host is required")
+	}
+
+	if c.Method != "" && !httpMethods[strings.ToUpper(c.Method)] {
+		return fmt.Errorf("http method %q is not supported", c.Method)
+	}
+
+	if _, err := template.New("body").Parse(c.BodyTemplate); err != nil {
+		return fmt.Errorf("http body_template is malformed: %v", err)
+	}
+
+	if _, err := regexp.Compile(c.SuccessBodyRegex); err != nil {
+		return fmt.Errorf("http success_body_regex is malformed: %v", err)
+	}
+
+	for _, r := range c.SuccessStatusCodes {
+		if r.Min > r.Max {
+			return fmt.Errorf("http success_status_codes range [%d, %d] is malformed", r.Min, r.Max)
+		}
+	}
+
+	return nil
+}
+
+// Execute implements Executor interface, Code of the result is the response status code. The request is bounded by
+// the job Timeout, or httpDefaultTimeout if the job has none.
+func (e *HTTPExecutor) Execute(ctx context.Context, job *Job) (*ExecutionResult, error) {
+	c := job.HTTP
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, httpDefaultTimeout)
+		defer cancel()
+	}
+
+	var body bytes.Buffer
+	tmpl, err := template.New("body").Parse(c.BodyTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(&body, &HTTPTemplateData{
+		JobID:          job.JobID,
+		Namespace:      job.Namespace,
+		JobKey:         job.JobKey,
+		JobDisplayName: job.JobDisplayName,
+		Labels:         job.Labels,
+		ScheduledTime:  ScheduledTimeFromContext(ctx),
+	}); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, c.method(), c.URL, &body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+	// The callee joins the trace of the run through W3C traceparent header.
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// One more byte than the cap is read to tell whether the body has been truncated, the rest is discarded unread.
+	output := newCappedBuffer(httpMaxResponseSize)
+	if _, err := io.Copy(output, io.LimitReader(resp.Body, httpMaxResponseSize+1)); err != nil {
+		return nil, err
+	}
+
+	result := &ExecutionResult{
+		Code:   int32(resp.StatusCode),
+		Output: output.String(),
+	}
+
+	if !c.isSuccessStatus(resp.StatusCode) {
+		return result, fmt.Errorf("unexpected http status code %d", resp.StatusCode)
+	}
+
+	if c.SuccessBodyRegex != "" {
+		matched, err := regexp.MatchString(c.SuccessBodyRegex, output.buf.String())
+		if err != nil {
+			return result, err
+		}
+		if !matched {
+			return result, errors.New("http response body mismatches success_body_regex")
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,32 @@
+package server
+
+import "testing"
+
+func TestHTTPExecutorValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *HTTPConfig
+		wantErr bool
+	}{
+		{name: "default method", config: &HTTPConfig{URL: "http://example.com/hook"}},
+		{name: "lower case method", config: &HTTPConfig{URL: "https://example.com:8443", Method: "post"}},
+		{name: "options", config: &HTTPConfig{URL: "https://example.com", Method: "OPTIONS"}},
+		{name: "missing config", wantErr: true},
+		{name: "connect", config: &HTTPConfig{URL: "https://example.com", Method: "CONNECT"}, wantErr: true},
+		{name: "trace", config: &HTTPConfig{URL: "https://example.com", Method: "TRACE"}, wantErr: true},
+		{name: "unknown method", config: &HTTPConfig{URL: "https://example.com", Method: "FETCH"}, wantErr: true},
+		{name: "unsupported scheme", config: &HTTPConfig{URL: "ftp://example.com"}, wantErr: true},
+		{name: "missing scheme", config: &HTTPConfig{URL: "example.com/hook"}, wantErr: true},
+		{name: "missing host", config: &HTTPConfig{URL: "http:///hook"}, wantErr: true},
+		{name: "opaque url", config: &HTTPConfig{URL: "http:example.com"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&HTTPExecutor{}).Validate(&Job{HTTP: tt.config})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/KevinWu0904/crond/proto/types"
+)
+
+const (
+	// shellMaxOutputSize is the maximum bytes of captured stdout/stderr for a single run.
+	shellMaxOutputSize = 64 * 1024
+	// shellOutputGracePeriod is how long output is still collected after the command exits, children left running in
+	// the background may hold the output pipe open much longer.
+	shellOutputGracePeriod = time.Second
+)
+
+// ShellConfig stores ShellExecutor specific configurations of a job.
+type ShellConfig struct {
+	Command    string            `json:"command"`
+	Args       []string          `json:"args,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+	WorkingDir string            `json:"working_dir,omitempty"`
+}
+
+// NewShellConfigFromProto converts types.ShellExecutorConfig into ShellConfig.
+func NewShellConfigFromProto(pb *types.ShellExecutorConfig) *ShellConfig {
+	if pb == nil {
+		return nil
+	}
+
+	return &ShellConfig{
+		Command:    pb.GetCommand(),
+		Args:       pb.GetArgs(),
+		Env:        pb.GetEnv(),
+		WorkingDir: pb.GetWorkingDir(),
+	}
+}
+
+// ToProto converts ShellConfig into types.ShellExecutorConfig.
+func (c *ShellConfig) ToProto() *types.ShellExecutorConfig {
+	return &types.ShellExecutorConfig{
+		Command:    c.Command,
+		Args:       c.Args,
+		Env:        c.Env,
+		WorkingDir: c.WorkingDir,
+	}
+}
+
+// Clone returns a deep copy of ShellConfig.
+func (c *ShellConfig) Clone() *ShellConfig {
+	clone := *c
+	clone.Args = append([]string(nil), c.Args...)
+	clone.Env = make(map[string]string, len(c.Env))
+	for k, v := range c.Env {
+		clone.Env[k] = v
+	}
+
+	return &clone
+}
+
+// ShellExecutor runs a command directly without an intermediate shell, scripts should be invoked by their own
+// interpreter, e.g. command "/bin/sh" with args ["-c", "..."].
+// The command is running in its own process group, the whole group will be killed once the run is canceled. The run
+// ends once the command exits, children left running in the background are not waited for.
+type ShellExecutor struct{}
+
+func init() {
+	RegisterExecutor(ExecutorTypeShell, &ShellExecutor{})
+}
+
+// Validate implements Executor interface.
+func (*ShellExecutor) Validate(job *Job) error {
+	if job.Shell == nil {
+		return errors.New("shell config is required")
+	}
+
+	if job.Shell.Command == "" {
+		return errors.New("shell command is required")
+	}
+
+	return nil
+}
+
+// Execute implements Executor interface, Code of the result is the command exit code.
+func (*ShellExecutor) Execute(ctx context.Context, job *Job) (*ExecutionResult, error) {
+	// nolint:gosec
+	cmd := exec.Command(job.Shell.Command, job.Shell.Args...)
+	cmd.Dir = job.Shell.WorkingDir
+	cmd.Env = os.Environ()
+	for k, v := range job.Shell.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	// Output is read from a pipe by ourselves rather than by exec.Cmd, whose Wait blocks until every holder of the
+	// pipe exits, including backgrounded children.
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer pr.Close()
+
+	output := newCappedBuffer(shellMaxOutputSize)
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	setProcessGroup(cmd)
+
+	err = cmd.Start()
+	pw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	copyDone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(output, pr)
+		close(copyDone)
+	}()
+
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- cmd.Wait()
+	}()
+
+	select {
+	case err = <-waitCh:
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-waitCh
+		err = ctx.Err()
+	}
+
+	select {
+	case <-copyDone:
+	case <-time.After(shellOutputGracePeriod):
+		// Closing the read end stops the copy, children writing afterwards get EPIPE.
+		pr.Close()
+		<-copyDone
+	}
+
+	result := &ExecutionResult{
+		Code:   int32(cmd.ProcessState.ExitCode()),
+		Output: output.String(),
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return result, fmt.Errorf("command exited with code %d", exitErr.ExitCode())
+	}
+
+	return result, err
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+// +build !windows
+
+package server
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes the command leader of a new process group, so its children can be killed together.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the whole process group led by the command.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
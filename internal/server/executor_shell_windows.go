@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package server
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on windows.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the command process only, its children will not be killed on windows.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/KevinWu0904/crond/proto/types"
+	"github.com/hashicorp/raft"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// forwardedMetadataKey marks requests which have been forwarded by a follower, they will never be forwarded twice.
+const forwardedMetadataKey = "x-crond-forwarded"
+
+// ErrNoLeader throws when the raft cluster has no available leader currently.
+var ErrNoLeader = errors.New("no raft leader")
+
+// LeaderForwarder forwards write requests from followers to the current raft leader.
+// Raft transport shares the same cmux port with gRPC, so the raft leader address is also the gRPC endpoint.
+type LeaderForwarder struct {
+	raftLayer *RaftLayer
+
+	sync.Mutex
+
+	addr raft.ServerAddress
+	conn *grpc.ClientConn
+}
+
+// NewLeaderForwarder creates LeaderForwarder.
+func NewLeaderForwarder(raftLayer *RaftLayer) *LeaderForwarder {
+	return &LeaderForwarder{
+		raftLayer: raftLayer,
+	}
+}
+
+// LeaderClient returns nil if the current node is raft leader, otherwise it returns a CrondClient connected to the
+// raft leader together with the context which should be used for the forwarded call.
+func (f *LeaderForwarder) LeaderClient(ctx context.Context) (types.CrondClient, context.Context, error) {
+	if f.raftLayer.IsLeader() {
+		return nil, ctx, nil
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(forwardedMetadataKey)) > 0 {
+		return nil, ctx, raft.ErrNotLeader
+	}
+
+	addr := f.raftLayer.LeaderAddr()
+	if addr == "" {
+		return nil, ctx, ErrNoLeader
+	}
+
+	conn, err := f.dial(addr)
+	if err != nil {
+		return nil, ctx, err
+	}
+
+	return types.NewCrondClient(conn), metadata.AppendToOutgoingContext(ctx, forwardedMetadataKey, "true"), nil
+}
+
+func (f *LeaderForwarder) dial(addr raft.ServerAddress) (*grpc.ClientConn, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.conn != nil && f.addr == addr {
+		return f.conn, nil
+	}
+
+	conn, err := grpc.Dial(string(addr), grpc.WithInsecure(), grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
+	if err != nil {
+		return nil, err
+	}
+
+	if f.conn != nil {
+		f.conn.Close()
+	}
+	f.addr = addr
+	f.conn = conn
+
+	return conn, nil
+}
+
+// Close releases the connection to raft leader.
+func (f *LeaderForwarder) Close() {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.conn != nil {
+		f.conn.Close()
+		f.conn = nil
+		f.addr = ""
+	}
+}
@@ -0,0 +1,630 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/KevinWu0904/crond/pkg/logs"
+	"github.com/hashicorp/raft"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrJobNotFound throws when the requested job does not exist.
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrJobAlreadyExists throws when creating a job whose JobID has already been taken.
+var ErrJobAlreadyExists = errors.New("job already exists")
+
+// ErrJobKeyConflict throws when another job in the same namespace has already taken the same job key.
+var ErrJobKeyConflict = errors.New("job key conflict")
+
+// ErrJobRunNotFound throws when the requested job run does not exist in run history.
+var ErrJobRunNotFound = errors.New("job run not found")
+
+// ErrUnknownCommand throws when JobFSM receives an unrecognized raft command.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// CommandType defines multiple raft command types, different type will be applied differently by JobFSM.
+type CommandType uint8
+
+const (
+	// CommandSetJob creates a new job or updates an existing job.
+	CommandSetJob CommandType = iota + 1
+	// CommandDeleteJob deletes an existing job.
+	CommandDeleteJob
+	// CommandCreateJob creates a new job, it fails if the job already exists.
+	CommandCreateJob
+	// CommandUpdateJob updates an existing job, it fails if the job does not exist.
+	CommandUpdateJob
+	// CommandSetJobRun creates or updates a run in run history.
+	CommandSetJobRun
+	// CommandPauseJob pauses an existing job and abandons its pending retries.
+	CommandPauseJob
+	// CommandResumeJob resumes an existing job.
+	CommandResumeJob
+	// CommandDeleteJobs deletes multiple jobs, jobs which do not exist are ignored.
+	CommandDeleteJobs
+	// CommandCompleteJob completes an existing job whose schedule has been exhausted, it is ignored otherwise.
+	CommandCompleteJob
+)
+
+// Command represents a single raft log entry submitted to JobFSM.
+type Command struct {
+	Type   CommandType `json:"type"`
+	Job    *Job        `json:"job,omitempty"`
+	JobID  string      `json:"job_id,omitempty"`
+	JobIDs []string    `json:"job_ids,omitempty"`
+	Run    *JobRun     `json:"run,omitempty"`
+	Time   time.Time   `json:"time,omitempty"` // Stamped by the proposer, commands must not read clock when applied.
+
+	Trace map[string]string `json:"trace,omitempty"` // Trace context of the proposer, it never affects the outcome.
+}
+
+// namespacedKey identifies a job by JobKey within its namespace.
+type namespacedKey struct {
+	Namespace string
+	JobKey    string
+}
+
+// JobFSMListener observes job changes applied by JobFSM, callbacks are invoked in raft apply goroutine.
+type JobFSMListener interface {
+	OnJobSet(job *Job)
+	OnJobDeleted(job *Job)
+	OnRestored(jobs []*Job)
+}
+
+// JobFSM implements raft.FSM, it keeps the replicated job table in memory.
+type JobFSM struct {
+	sync.RWMutex
+
+	jobs  map[string]*Job          // JobID -> Job
+	keys  map[namespacedKey]string // (Namespace, JobKey) -> JobID
+	runs  map[string][]*JobRun     // JobID -> JobRuns ordered by start time, at most historyLimit runs per job
+	index uint64                   // Raft log index of the latest applied command
+
+	historyLimit int
+	listeners    []JobFSMListener
+	events       *EventHub
+}
+
+// NewJobFSM creates JobFSM, it keeps at most historyLimit runs for each job and publishes applied changes to events.
+func NewJobFSM(historyLimit int, events *EventHub) *JobFSM {
+	return &JobFSM{
+		jobs:         make(map[string]*Job),
+		keys:         make(map[namespacedKey]string),
+		runs:         make(map[string][]*JobRun),
+		historyLimit: historyLimit,
+		events:       events,
+	}
+}
+
+// AddListener registers a JobFSMListener.
+func (f *JobFSM) AddListener(listener JobFSMListener) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.listeners = append(f.listeners, listener)
+}
+
+// getListeners returns a copy of registered listeners, so that listeners can be notified without lock.
+func (f *JobFSM) getListeners() []JobFSMListener {
+	f.RLock()
+	defer f.RUnlock()
+
+	return append([]JobFSMListener(nil), f.listeners...)
+}
+
+// Apply implements raft.FSM interface, it returns the stored entities or an error.
+func (f *JobFSM) Apply(log *raft.Log) interface{} {
+	// The index advances once the command has taken effect, so readiness never observes a half applied command.
+	defer func() {
+		f.Lock()
+		f.index = log.Index
+		f.Unlock()
+	}()
+
+	var cmd Command
+	if err := json.Unmarshal(log.Data, &cmd); err != nil {
+		logs.Error("JobFSM failed to decode command: index=%d, err=%v", log.Index, err)
+		return err
+	}
+
+	if len(cmd.Trace) > 0 {
+		ctx := otel.GetTextMapPropagator().Extract(context.Background(), mapCarrier(cmd.Trace))
+		_, span := tracer.Start(ctx, "JobFSM.Apply", trace.WithAttributes(
+			attribute.Int64("crond.raft_index", int64(log.Index)),
+			attribute.Int("crond.command_type", int(cmd.Type)),
+		))
+		defer span.End()
+	}
+
+	switch cmd.Type {
+	case CommandSetJob:
+		return f.applySetJob(log.Index, cmd.Job, false, false)
+	case CommandCreateJob:
+		return f.applySetJob(log.Index, cmd.Job, true, false)
+	case CommandUpdateJob:
+		return f.applySetJob(log.Index, cmd.Job, false, true)
+	case CommandDeleteJob:
+		return f.applyDeleteJob(log.Index, cmd.JobID)
+	case CommandDeleteJobs:
+		return f.applyDeleteJobs(log.Index, cmd.JobIDs)
+	case CommandSetJobRun:
+		return f.applySetJobRun(log.Index, cmd.Run)
+	case CommandPauseJob:
+		return f.applySetJobPaused(log.Index, cmd.JobID, true, cmd.Time)
+	case CommandResumeJob:
+		return f.applySetJobPaused(log.Index, cmd.JobID, false, cmd.Time)
+	case CommandCompleteJob:
+		return f.applyCompleteJob(log.Index, cmd.JobID, cmd.Time)
+	default:
+		logs.Error("JobFSM received unknown command: index=%d, type=%d", log.Index, cmd.Type)
+		return ErrUnknownCommand
+	}
+}
+
+func (f *JobFSM) applySetJob(index uint64, job *Job, mustCreate, mustExist bool) interface{} {
+	f.Lock()
+
+	old, exists := f.jobs[job.JobID]
+	if mustCreate && exists {
+		f.Unlock()
+		return ErrJobAlreadyExists
+	}
+	if mustExist && !exists {
+		f.Unlock()
+		return ErrJobNotFound
+	}
+
+	// An empty namespace keeps the namespace of the existing job, a job can not be moved to another namespace.
+	switch {
+	case job.Namespace == "" && exists:
+		job.Namespace = old.Namespace
+	case job.Namespace == "":
+		job.Namespace = DefaultNamespace
+	case exists && job.Namespace != old.Namespace:
+		f.Unlock()
+		return fmt.Errorf("%w: namespace can not be changed", ErrInvalidJob)
+	}
+
+	key := job.namespacedKey()
+	if id, ok := f.keys[key]; ok && id != job.JobID {
+		f.Unlock()
+		return ErrJobKeyConflict
+	}
+
+	// LastScheduledTime and CompleteTime are owned by JobFSM, they are never taken from clients. A completed job stays
+	// completed unless it is updated to fire after its last scheduled run. Paused can only be set on creation, later it
+	// is changed by pause and resume commands.
+	job.LastScheduledTime = time.Time{}
+	job.CompleteTime = time.Time{}
+	if exists {
+		job.Paused = old.Paused
+		job.LastScheduledTime = old.LastScheduledTime
+		if !old.CompleteTime.IsZero() && job.exhausted(old.LastScheduledTime) {
+			job.CompleteTime = old.CompleteTime
+		}
+		delete(f.keys, old.namespacedKey())
+	}
+
+	f.jobs[job.JobID] = job
+	f.keys[key] = job.JobID
+	f.Unlock()
+
+	for _, listener := range f.getListeners() {
+		listener.OnJobSet(job.Clone())
+	}
+
+	eventType := JobEventTypeJobCreated
+	if exists {
+		eventType = JobEventTypeJobUpdated
+	}
+	f.publish(&JobEvent{Revision: index, Type: eventType, Job: job.Clone()})
+
+	return job.Clone()
+}
+
+func (f *JobFSM) applyDeleteJob(index uint64, jobID string) interface{} {
+	f.Lock()
+
+	job, ok := f.jobs[jobID]
+	if !ok {
+		f.Unlock()
+		return ErrJobNotFound
+	}
+
+	delete(f.jobs, jobID)
+	delete(f.keys, job.namespacedKey())
+	delete(f.runs, jobID)
+	f.Unlock()
+
+	for _, listener := range f.getListeners() {
+		listener.OnJobDeleted(job.Clone())
+	}
+
+	f.publish(&JobEvent{Revision: index, Type: JobEventTypeJobDeleted, Job: job.Clone()})
+
+	return job.Clone()
+}
+
+// applyDeleteJobs returns the deleted []*Job.
+func (f *JobFSM) applyDeleteJobs(index uint64, jobIDs []string) interface{} {
+	f.Lock()
+
+	deleted := make([]*Job, 0, len(jobIDs))
+	for _, jobID := range jobIDs {
+		job, ok := f.jobs[jobID]
+		if !ok {
+			continue
+		}
+
+		delete(f.jobs, jobID)
+		delete(f.keys, job.namespacedKey())
+		delete(f.runs, jobID)
+		deleted = append(deleted, job)
+	}
+	f.Unlock()
+
+	listeners := f.getListeners()
+	events := make([]*JobEvent, 0, len(deleted))
+	for _, job := range deleted {
+		for _, listener := range listeners {
+			listener.OnJobDeleted(job.Clone())
+		}
+		events = append(events, &JobEvent{Revision: index, Type: JobEventTypeJobDeleted, Job: job.Clone()})
+	}
+
+	f.publish(events...)
+
+	return deleted
+}
+
+func (f *JobFSM) applySetJobPaused(index uint64, jobID string, paused bool, updateTime time.Time) interface{} {
+	f.Lock()
+
+	job, ok := f.jobs[jobID]
+	if !ok {
+		f.Unlock()
+		return ErrJobNotFound
+	}
+
+	job.Paused = paused
+	job.UpdateTime = updateTime
+
+	if paused {
+		for _, run := range f.runs[jobID] {
+			run.NextRetryTime = time.Time{}
+		}
+	}
+	f.Unlock()
+
+	for _, listener := range f.getListeners() {
+		listener.OnJobSet(job.Clone())
+	}
+
+	f.publish(&JobEvent{Revision: index, Type: JobEventTypeJobUpdated, Job: job.Clone()})
+
+	return job.Clone()
+}
+
+func (f *JobFSM) applyCompleteJob(index uint64, jobID string, completeTime time.Time) interface{} {
+	f.Lock()
+
+	job, ok := f.jobs[jobID]
+	if !ok {
+		f.Unlock()
+		return ErrJobNotFound
+	}
+
+	// The job may have been updated to fire again since completion was proposed.
+	if !job.CompleteTime.IsZero() || !job.exhausted(completeTime) {
+		f.Unlock()
+		return job.Clone()
+	}
+
+	job.CompleteTime = completeTime
+	f.Unlock()
+
+	for _, listener := range f.getListeners() {
+		listener.OnJobSet(job.Clone())
+	}
+
+	f.publish(&JobEvent{Revision: index, Type: JobEventTypeJobCompleted, Job: job.Clone()})
+
+	return job.Clone()
+}
+
+func (f *JobFSM) applySetJobRun(index uint64, run *JobRun) interface{} {
+	f.Lock()
+
+	job, ok := f.jobs[run.JobID]
+	if !ok {
+		f.Unlock()
+		return ErrJobNotFound
+	}
+
+	// Retries share the scheduled time of the failed run, only the first attempt stands for a fire. Manual runs
+	// are not fired by the schedule.
+	if run.RetryOf == "" && run.Trigger != JobRunTriggerManual && run.ScheduledTime.After(job.LastScheduledTime) {
+		job.LastScheduledTime = run.ScheduledTime
+	}
+
+	runs := f.runs[run.JobID]
+
+	// The failed run is no longer waiting for retry once the retry has been made.
+	if run.RetryOf != "" {
+		for _, prev := range runs {
+			if prev.RunID == run.RetryOf {
+				prev.NextRetryTime = time.Time{}
+			}
+		}
+	}
+
+	var old *JobRun
+	for i := range runs {
+		if runs[i].RunID == run.RunID {
+			old, runs[i] = runs[i], run
+			break
+		}
+	}
+
+	if old == nil {
+		runs = append(runs, run)
+		if len(runs) > f.historyLimit {
+			runs = compactJobRuns(runs, f.historyLimit)
+		}
+		f.runs[run.JobID] = runs
+	}
+	job = job.Clone()
+	f.Unlock()
+
+	// A run recorded as finished at once, e.g. skipped by concurrency policy, only emits the finished event.
+	switch {
+	case run.Status.Finished() && (old == nil || !old.Status.Finished()):
+		f.publish(&JobEvent{Revision: index, Type: JobEventTypeRunFinished, Job: job, Run: run.Clone()})
+	case run.Status == JobRunStatusRunning && old == nil:
+		f.publish(&JobEvent{Revision: index, Type: JobEventTypeRunStarted, Job: job, Run: run.Clone()})
+	}
+
+	return run.Clone()
+}
+
+// publish sends events to EventHub, it must be called without lock.
+func (f *JobFSM) publish(events ...*JobEvent) {
+	if f.events != nil && len(events) > 0 {
+		f.events.Publish(events...)
+	}
+}
+
+// compactJobRuns drops the oldest finished runs until at most limit runs remain, running runs are kept unless
+// there are too many of them.
+func compactJobRuns(runs []*JobRun, limit int) []*JobRun {
+	drop := len(runs) - limit
+	kept := make([]*JobRun, 0, limit)
+	for _, run := range runs {
+		if drop > 0 && run.Status.Finished() {
+			drop--
+			continue
+		}
+		kept = append(kept, run)
+	}
+
+	if len(kept) > limit {
+		kept = kept[len(kept)-limit:]
+	}
+
+	return kept
+}
+
+// GetJob searches a job by JobID.
+func (f *JobFSM) GetJob(jobID string) (*Job, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	job, ok := f.jobs[jobID]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	return job.Clone(), nil
+}
+
+// ListJobs returns all jobs in the replicated job table.
+func (f *JobFSM) ListJobs() []*Job {
+	f.RLock()
+	defer f.RUnlock()
+
+	jobs := make([]*Job, 0, len(f.jobs))
+	for _, job := range f.jobs {
+		jobs = append(jobs, job.Clone())
+	}
+
+	return jobs
+}
+
+// AppliedIndex returns the raft log index of the latest command applied by JobFSM.
+func (f *JobFSM) AppliedIndex() uint64 {
+	f.RLock()
+	defer f.RUnlock()
+
+	return f.index
+}
+
+// CountJobs counts jobs in the replicated job table by state without copying them.
+func (f *JobFSM) CountJobs() (active, paused, completed int) {
+	f.RLock()
+	defer f.RUnlock()
+
+	for _, job := range f.jobs {
+		switch {
+		case !job.CompleteTime.IsZero():
+			completed++
+		case job.Paused:
+			paused++
+		default:
+			active++
+		}
+	}
+
+	return active, paused, completed
+}
+
+// GetJobRun searches a run of the job by RunID.
+func (f *JobFSM) GetJobRun(jobID, runID string) (*JobRun, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	if _, ok := f.jobs[jobID]; !ok {
+		return nil, ErrJobNotFound
+	}
+
+	for _, run := range f.runs[jobID] {
+		if run.RunID == runID {
+			return run.Clone(), nil
+		}
+	}
+
+	return nil, ErrJobRunNotFound
+}
+
+// ListJobRuns returns run history of the job, the latest run comes first.
+func (f *JobFSM) ListJobRuns(jobID string) ([]*JobRun, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	if _, ok := f.jobs[jobID]; !ok {
+		return nil, ErrJobNotFound
+	}
+
+	runs := f.runs[jobID]
+	result := make([]*JobRun, 0, len(runs))
+	for i := len(runs) - 1; i >= 0; i-- {
+		result = append(result, runs[i].Clone())
+	}
+
+	return result, nil
+}
+
+// ListRunningRuns returns all runs which have not finished yet.
+func (f *JobFSM) ListRunningRuns() []*JobRun {
+	return f.filterRuns(func(run *JobRun) bool {
+		return run.Status == JobRunStatusRunning
+	})
+}
+
+// ListPendingRetries returns all failed runs which are waiting for retry.
+func (f *JobFSM) ListPendingRetries() []*JobRun {
+	return f.filterRuns(func(run *JobRun) bool {
+		return !run.NextRetryTime.IsZero()
+	})
+}
+
+func (f *JobFSM) filterRuns(filter func(run *JobRun) bool) []*JobRun {
+	f.RLock()
+	defer f.RUnlock()
+
+	var result []*JobRun
+	for _, runs := range f.runs {
+		for _, run := range runs {
+			if filter(run) {
+				result = append(result, run.Clone())
+			}
+		}
+	}
+
+	return result
+}
+
+// Snapshot implements raft.FSM interface.
+func (f *JobFSM) Snapshot() (raft.FSMSnapshot, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	snapshot := &JobFSMSnapshot{
+		Index: f.index,
+		Jobs:  make([]*Job, 0, len(f.jobs)),
+		Runs:  make([]*JobRun, 0, len(f.runs)),
+	}
+	for _, job := range f.jobs {
+		snapshot.Jobs = append(snapshot.Jobs, job.Clone())
+	}
+	for _, runs := range f.runs {
+		for _, run := range runs {
+			snapshot.Runs = append(snapshot.Runs, run.Clone())
+		}
+	}
+
+	return snapshot, nil
+}
+
+// Restore implements raft.FSM interface, it replaces the whole job table with the snapshot.
+func (f *JobFSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	var snapshot JobFSMSnapshot
+	if err := json.NewDecoder(rc).Decode(&snapshot); err != nil {
+		logs.Error("JobFSM failed to decode snapshot: err=%v", err)
+		return err
+	}
+
+	jobs := make(map[string]*Job, len(snapshot.Jobs))
+	keys := make(map[namespacedKey]string, len(snapshot.Jobs))
+	for _, job := range snapshot.Jobs {
+		// Jobs created before namespaces were introduced belong to the default namespace.
+		if job.Namespace == "" {
+			job.Namespace = DefaultNamespace
+		}
+		jobs[job.JobID] = job
+		keys[job.namespacedKey()] = job.JobID
+	}
+
+	runs := make(map[string][]*JobRun, len(snapshot.Jobs))
+	for _, run := range snapshot.Runs {
+		runs[run.JobID] = append(runs[run.JobID], run)
+	}
+
+	f.Lock()
+	f.jobs = jobs
+	f.keys = keys
+	f.runs = runs
+	f.index = snapshot.Index
+	f.Unlock()
+
+	for _, listener := range f.getListeners() {
+		listener.OnRestored(f.ListJobs())
+	}
+
+	if f.events != nil {
+		f.events.Reset(snapshot.Index)
+	}
+
+	logs.Info("JobFSM restored from snapshot: jobs=%d", len(jobs))
+	return nil
+}
+
+// JobFSMSnapshot implements raft.FSMSnapshot, it is a point-in-time copy of JobFSM.
+type JobFSMSnapshot struct {
+	Index uint64    `json:"index"`
+	Jobs  []*Job    `json:"jobs"`
+	Runs  []*JobRun `json:"runs"`
+}
+
+// Persist implements raft.FSMSnapshot interface.
+func (s *JobFSMSnapshot) Persist(sink raft.SnapshotSink) error {
+	if err := json.NewEncoder(sink).Encode(s); err != nil {
+		sink.Cancel()
+		return err
+	}
+
+	return sink.Close()
+}
+
+// Release implements raft.FSMSnapshot interface.
+func (s *JobFSMSnapshot) Release() {}
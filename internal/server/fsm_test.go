@@ -0,0 +1,259 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// applyCommand applies the command to fsm as raft would at index.
+func applyCommand(t *testing.T, fsm *JobFSM, index uint64, cmd *Command) interface{} {
+	t.Helper()
+
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: err=%v", err)
+	}
+
+	return fsm.Apply(&raft.Log{Index: index, Type: raft.LogCommand, Data: data})
+}
+
+// applyTestCommand applies the command like applyCommand, it fails the test if the command is rejected.
+func applyTestCommand(t *testing.T, fsm *JobFSM, index uint64, cmd *Command) interface{} {
+	t.Helper()
+
+	resp := applyCommand(t, fsm, index, cmd)
+	if err, ok := resp.(error); ok {
+		t.Fatalf("Apply failed: index=%d, type=%d, err=%v", index, cmd.Type, err)
+	}
+	return resp
+}
+
+// memorySink implements raft.SnapshotSink interface in memory.
+type memorySink struct {
+	bytes.Buffer
+	canceled bool
+}
+
+func (s *memorySink) ID() string {
+	return "memory"
+}
+
+func (s *memorySink) Cancel() error {
+	s.canceled = true
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	return nil
+}
+
+// fsmState is the comparable content of JobFSM, times are compared as JSON like they are replicated.
+func fsmState(t *testing.T, fsm *JobFSM) string {
+	t.Helper()
+
+	jobs := fsm.ListJobs()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].JobID < jobs[j].JobID })
+
+	state := map[string]interface{}{"index": fsm.AppliedIndex(), "jobs": jobs}
+	for _, job := range jobs {
+		runs, err := fsm.ListJobRuns(job.JobID)
+		if err != nil {
+			t.Fatalf("ListJobRuns failed: err=%v", err)
+		}
+		state["runs/"+job.JobID] = runs
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: err=%v", err)
+	}
+	return string(data)
+}
+
+func TestJobFSMSnapshotRestore(t *testing.T) {
+	fsm := NewJobFSM(2, nil)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	applyTestCommand(t, fsm, 1, &Command{Type: CommandCreateJob, Job: newTestJob("1", "a", "first", "First"), Time: now})
+	applyTestCommand(t, fsm, 2, &Command{Type: CommandCreateJob, Job: newTestJob("2", "b", "second", "Second"), Time: now})
+	applyTestCommand(t, fsm, 3, &Command{Type: CommandPauseJob, JobID: "2", Time: now})
+	for i, status := range []JobRunStatus{JobRunStatusSucceeded, JobRunStatusFailed, JobRunStatusRunning} {
+		scheduled := now.Add(time.Duration(i) * time.Minute)
+		run := &JobRun{RunID: string(rune('a' + i)), JobID: "1", ScheduledTime: scheduled, StartTime: scheduled,
+			Status: status, Attempt: 1}
+		if status.Finished() {
+			run.EndTime = scheduled.Add(time.Second)
+		}
+		applyTestCommand(t, fsm, uint64(4+i), &Command{Type: CommandSetJobRun, Run: run})
+	}
+
+	snapshot, err := fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: err=%v", err)
+	}
+	sink := &memorySink{}
+	if err := snapshot.Persist(sink); err != nil || sink.canceled {
+		t.Fatalf("Persist failed: err=%v, canceled=%v", err, sink.canceled)
+	}
+	snapshot.Release()
+
+	restored := NewJobFSM(2, nil)
+	if err := restored.Restore(io.NopCloser(&sink.Buffer)); err != nil {
+		t.Fatalf("Restore failed: err=%v", err)
+	}
+
+	if got, want := fsmState(t, restored), fsmState(t, fsm); got != want {
+		t.Errorf("restored state = %s, want %s", got, want)
+	}
+	if runs := restored.ListRunningRuns(); len(runs) != 1 || runs[0].RunID != "c" {
+		t.Errorf("ListRunningRuns() = %v, want run c", runs)
+	}
+
+	// Job keys are restored along with jobs.
+	resp := applyCommand(t, restored, 7, &Command{Type: CommandCreateJob, Job: newTestJob("3", "a", "first", ""), Time: now})
+	if err, ok := resp.(error); !ok || !errors.Is(err, ErrJobKeyConflict) {
+		t.Errorf("Apply() = %v, want ErrJobKeyConflict", resp)
+	}
+}
+
+func TestJobFSMRestoreReplacesState(t *testing.T) {
+	fsm := NewJobFSM(2, nil)
+	applyTestCommand(t, fsm, 1, &Command{Type: CommandCreateJob, Job: newTestJob("1", "a", "first", "")})
+
+	snapshot, err := NewJobFSM(2, nil).Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: err=%v", err)
+	}
+	sink := &memorySink{}
+	if err := snapshot.Persist(sink); err != nil {
+		t.Fatalf("Persist failed: err=%v", err)
+	}
+
+	if err := fsm.Restore(io.NopCloser(&sink.Buffer)); err != nil {
+		t.Fatalf("Restore failed: err=%v", err)
+	}
+	if jobs := fsm.ListJobs(); len(jobs) != 0 {
+		t.Errorf("ListJobs() = %v, want no job", jobs)
+	}
+	if index := fsm.AppliedIndex(); index != 0 {
+		t.Errorf("AppliedIndex() = %d, want 0", index)
+	}
+}
+
+func TestJobFSMApply(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		cmds    []*Command
+		wantErr error
+		check   func(t *testing.T, fsm *JobFSM)
+	}{
+		{
+			name: "create existing job",
+			cmds: []*Command{
+				{Type: CommandCreateJob, Job: newTestJob("1", "a", "first", "")},
+				{Type: CommandCreateJob, Job: newTestJob("1", "a", "other", "")},
+			},
+			wantErr: ErrJobAlreadyExists,
+		},
+		{
+			name:    "update missing job",
+			cmds:    []*Command{{Type: CommandUpdateJob, Job: newTestJob("1", "a", "first", "")}},
+			wantErr: ErrJobNotFound,
+		},
+		{
+			name: "job key taken in namespace",
+			cmds: []*Command{
+				{Type: CommandCreateJob, Job: newTestJob("1", "a", "first", "")},
+				{Type: CommandCreateJob, Job: newTestJob("2", "a", "first", "")},
+			},
+			wantErr: ErrJobKeyConflict,
+		},
+		{
+			name: "same job key in other namespace",
+			cmds: []*Command{
+				{Type: CommandCreateJob, Job: newTestJob("1", "a", "first", "")},
+				{Type: CommandCreateJob, Job: newTestJob("2", "b", "first", "")},
+			},
+		},
+		{
+			name: "update keeps paused",
+			cmds: []*Command{
+				{Type: CommandCreateJob, Job: newTestJob("1", "a", "first", "")},
+				{Type: CommandPauseJob, JobID: "1", Time: now},
+				{Type: CommandUpdateJob, Job: newTestJob("1", "a", "first", "Renamed")},
+			},
+			check: func(t *testing.T, fsm *JobFSM) {
+				job, err := fsm.GetJob("1")
+				if err != nil || !job.Paused || job.JobDisplayName != "Renamed" {
+					t.Errorf("GetJob() = %+v, err %v, want paused and renamed", job, err)
+				}
+			},
+		},
+		{
+			name: "run history is compacted",
+			cmds: []*Command{
+				{Type: CommandCreateJob, Job: newTestJob("1", "a", "first", "")},
+				{Type: CommandSetJobRun, Run: &JobRun{RunID: "a", JobID: "1", Status: JobRunStatusSucceeded}},
+				{Type: CommandSetJobRun, Run: &JobRun{RunID: "b", JobID: "1", Status: JobRunStatusSucceeded}},
+				{Type: CommandSetJobRun, Run: &JobRun{RunID: "c", JobID: "1", Status: JobRunStatusSucceeded}},
+			},
+			check: func(t *testing.T, fsm *JobFSM) {
+				runs, _ := fsm.ListJobRuns("1")
+				var ids []string
+				for _, run := range runs {
+					ids = append(ids, run.RunID)
+				}
+				if want := []string{"c", "b"}; !reflect.DeepEqual(ids, want) {
+					t.Errorf("ListJobRuns() = %v, want %v", ids, want)
+				}
+			},
+		},
+		{
+			name: "run of missing job",
+			cmds: []*Command{
+				{Type: CommandSetJobRun, Run: &JobRun{RunID: "a", JobID: "1", Status: JobRunStatusRunning}},
+			},
+			wantErr: ErrJobNotFound,
+		},
+		{
+			name:    "unknown command",
+			cmds:    []*Command{{Type: CommandType(255)}},
+			wantErr: ErrUnknownCommand,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsm := NewJobFSM(2, nil)
+
+			var resp interface{}
+			for i, cmd := range tt.cmds {
+				resp = applyCommand(t, fsm, uint64(i+1), cmd)
+				if err, ok := resp.(error); ok && i < len(tt.cmds)-1 {
+					t.Fatalf("Apply failed: index=%d, err=%v", i+1, err)
+				}
+			}
+
+			err, _ := resp.(error)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("Apply() err = %v, want %v", err, tt.wantErr)
+			}
+			if got := fsm.AppliedIndex(); got != uint64(len(tt.cmds)) {
+				t.Errorf("AppliedIndex() = %d, want %d", got, len(tt.cmds))
+			}
+			if tt.check != nil {
+				tt.check(t, fsm)
+			}
+		})
+	}
+}
@@ -2,31 +2,433 @@ package server
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/KevinWu0904/crond/proto/types"
+	"github.com/hashicorp/raft"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // CrondGRPCService serves crond gRPC protocol APIs.
 type CrondGRPCService struct {
 	types.UnimplementedCrondServer
+
+	jobService     *JobService
+	clusterService *ClusterService
+	forwarder      *LeaderForwarder
 }
 
 // NewCrondGRPCService creates CrondGRPCService.
-func NewCrondGRPCService() *CrondGRPCService {
-	return &CrondGRPCService{}
+func NewCrondGRPCService(jobService *JobService, clusterService *ClusterService, forwarder *LeaderForwarder) *CrondGRPCService {
+	return &CrondGRPCService{
+		jobService:     jobService,
+		clusterService: clusterService,
+		forwarder:      forwarder,
+	}
 }
 
 // SetJob provides gRPC API for users to create or update a job.
 func (s *CrondGRPCService) SetJob(ctx context.Context, req *types.SetJobRequest) (*types.SetJobResponse, error) {
-	panic("implement me")
+	if req.GetJob() == nil {
+		return nil, status.Error(codes.InvalidArgument, "job is required")
+	}
+
+	client, fctx, err := s.forwarder.LeaderClient(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	if client != nil {
+		return client.SetJob(fctx, req)
+	}
+
+	job, err := s.jobService.SetJob(ctx, NewJobFromProto(req.GetJob()))
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &types.SetJobResponse{Job: job.ToProto()}, nil
 }
 
 // GetJob provides gRPC API for users to search a job.
 func (s *CrondGRPCService) GetJob(ctx context.Context, req *types.GetJobRequest) (*types.GetJobResponse, error) {
-	panic("implement me")
+	if req.GetJobId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "job_id is required")
+	}
+
+	job, err := s.jobService.GetJob(ctx, req.GetJobId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &types.GetJobResponse{Job: job.ToProto()}, nil
+}
+
+// ListJobs provides gRPC API for users to browse jobs.
+func (s *CrondGRPCService) ListJobs(ctx context.Context, req *types.ListJobsRequest) (*types.ListJobsResponse, error) {
+	selector, err := ParseLabelSelector(req.GetLabelSelector())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	filter := &JobFilter{
+		Namespace:    req.GetNamespace(),
+		KeyPrefix:    req.GetKeyPrefix(),
+		ExecutorType: ExecutorType(req.GetExecutorType()),
+		Paused:       req.Paused,
+		Selector:     selector,
+	}
+
+	jobs, nextPageToken, err := s.jobService.ListJobs(ctx, filter, JobOrderBy(req.GetOrderBy()), req.GetDescending(),
+		int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	resp := &types.ListJobsResponse{NextPageToken: nextPageToken}
+	for _, job := range jobs {
+		resp.Jobs = append(resp.Jobs, job.ToProto())
+	}
+
+	return resp, nil
 }
 
 // DeleteJob provides gRPC API for users to delete a job.
 func (s *CrondGRPCService) DeleteJob(ctx context.Context, req *types.DeleteJobRequest) (*types.DeleteJobResponse, error) {
-	panic("implement me")
+	if req.GetJobId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "job_id is required")
+	}
+
+	client, fctx, err := s.forwarder.LeaderClient(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	if client != nil {
+		return client.DeleteJob(fctx, req)
+	}
+
+	if _, err := s.jobService.DeleteJob(ctx, req.GetJobId()); err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &types.DeleteJobResponse{}, nil
+}
+
+// DeleteJobs provides gRPC API for users to delete jobs selected by labels in a namespace.
+func (s *CrondGRPCService) DeleteJobs(ctx context.Context, req *types.DeleteJobsRequest) (*types.DeleteJobsResponse, error) {
+	if req.GetNamespace() == "" {
+		return nil, status.Error(codes.InvalidArgument, "namespace is required")
+	}
+
+	selector, err := ParseLabelSelector(req.GetLabelSelector())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	client, fctx, err := s.forwarder.LeaderClient(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	if client != nil {
+		return client.DeleteJobs(fctx, req)
+	}
+
+	jobs, err := s.jobService.DeleteJobs(ctx, &JobFilter{Namespace: req.GetNamespace(), Selector: selector})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	resp := &types.DeleteJobsResponse{}
+	for _, job := range jobs {
+		resp.Jobs = append(resp.Jobs, job.ToProto())
+	}
+
+	return resp, nil
+}
+
+// PauseJob provides gRPC API for users to pause a job.
+func (s *CrondGRPCService) PauseJob(ctx context.Context, req *types.PauseJobRequest) (*types.PauseJobResponse, error) {
+	if req.GetJobId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "job_id is required")
+	}
+
+	client, fctx, err := s.forwarder.LeaderClient(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	if client != nil {
+		return client.PauseJob(fctx, req)
+	}
+
+	job, err := s.jobService.PauseJob(ctx, req.GetJobId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &types.PauseJobResponse{Job: job.ToProto()}, nil
+}
+
+// ResumeJob provides gRPC API for users to resume a paused job.
+func (s *CrondGRPCService) ResumeJob(ctx context.Context, req *types.ResumeJobRequest) (*types.ResumeJobResponse, error) {
+	if req.GetJobId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "job_id is required")
+	}
+
+	client, fctx, err := s.forwarder.LeaderClient(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	if client != nil {
+		return client.ResumeJob(fctx, req)
+	}
+
+	job, err := s.jobService.ResumeJob(ctx, req.GetJobId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &types.ResumeJobResponse{Job: job.ToProto()}, nil
+}
+
+// TriggerJob provides gRPC API for users to run a job on demand.
+func (s *CrondGRPCService) TriggerJob(ctx context.Context, req *types.TriggerJobRequest) (*types.TriggerJobResponse, error) {
+	if req.GetJobId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "job_id is required")
+	}
+
+	client, fctx, err := s.forwarder.LeaderClient(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	if client != nil {
+		return client.TriggerJob(fctx, req)
+	}
+
+	run, err := s.jobService.TriggerJob(ctx, req.GetJobId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &types.TriggerJobResponse{Run: run.ToProto()}, nil
+}
+
+// GetJobRun provides gRPC API for users to search a run in run history.
+func (s *CrondGRPCService) GetJobRun(ctx context.Context, req *types.GetJobRunRequest) (*types.GetJobRunResponse, error) {
+	if req.GetJobId() == "" || req.GetRunId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "job_id and run_id are required")
+	}
+
+	run, err := s.jobService.GetJobRun(ctx, req.GetJobId(), req.GetRunId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &types.GetJobRunResponse{Run: run.ToProto()}, nil
+}
+
+// ListJobRuns provides gRPC API for users to browse run history of a job.
+func (s *CrondGRPCService) ListJobRuns(ctx context.Context, req *types.ListJobRunsRequest) (*types.ListJobRunsResponse, error) {
+	if req.GetJobId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "job_id is required")
+	}
+
+	runs, nextPageToken, err := s.jobService.ListJobRuns(ctx, req.GetJobId(), int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	resp := &types.ListJobRunsResponse{NextPageToken: nextPageToken}
+	for _, run := range runs {
+		resp.Runs = append(resp.Runs, run.ToProto())
+	}
+
+	return resp, nil
+}
+
+// PreviewSchedule provides gRPC API for users to validate a schedule and preview its next fire times, a malformed
+// schedule is reported in the response.
+func (s *CrondGRPCService) PreviewSchedule(ctx context.Context, req *types.PreviewScheduleRequest) (*types.PreviewScheduleResponse, error) {
+	start := time.Now()
+	if req.GetStartTime() != nil {
+		start = req.GetStartTime().AsTime()
+	}
+
+	times, err := s.jobService.PreviewSchedule(ctx, req.GetCronExpression(), req.GetTimeZone(), start,
+		int(req.GetCount()))
+	var scheduleErr *ScheduleError
+	if errors.As(err, &scheduleErr) {
+		return &types.PreviewScheduleResponse{Error: scheduleErr.ToProto()}, nil
+	}
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	resp := &types.PreviewScheduleResponse{}
+	for _, t := range times {
+		resp.NextFireTimes = append(resp.NextFireTimes, timestamppb.New(t))
+	}
+
+	return resp, nil
+}
+
+// WatchJobs provides gRPC API for users to stream job events, it resumes after the revision if it is not zero.
+func (s *CrondGRPCService) WatchJobs(req *types.WatchJobsRequest, stream types.Crond_WatchJobsServer) error {
+	selector, err := ParseLabelSelector(req.GetLabelSelector())
+	if err != nil {
+		return toGRPCError(err)
+	}
+
+	filter := &JobFilter{Namespace: req.GetNamespace(), Selector: selector}
+	watcher, err := s.jobService.WatchJobs(stream.Context(), req.GetRevision(), filter, req.GetJobId())
+	if err != nil {
+		return toGRPCError(err)
+	}
+	defer watcher.Close()
+
+	return sendEvents(stream.Context(), watcher, stream.Send)
+}
+
+// WatchRuns provides gRPC API for users to stream run events, it resumes after the revision if it is not zero.
+func (s *CrondGRPCService) WatchRuns(req *types.WatchRunsRequest, stream types.Crond_WatchRunsServer) error {
+	watcher, err := s.jobService.WatchRuns(stream.Context(), req.GetRevision(), req.GetNamespace(), req.GetJobId())
+	if err != nil {
+		return toGRPCError(err)
+	}
+	defer watcher.Close()
+
+	return sendEvents(stream.Context(), watcher, stream.Send)
+}
+
+// sendEvents streams events of the watcher until the client goes away or the watcher stops, the stream ends with the
+// reason of the stop if there is one.
+func sendEvents(ctx context.Context, watcher *Watcher, send func(*types.WatchEvent) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events():
+			if !ok {
+				// Err is nil when the watcher was closed by its owner.
+				if err := watcher.Err(); err != nil {
+					return toGRPCError(err)
+				}
+				return nil
+			}
+			if err := send(event.ToProto()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// AddVoter provides gRPC API for operators to add a voting server into the cluster.
+func (s *CrondGRPCService) AddVoter(ctx context.Context, req *types.AddVoterRequest) (*types.AddVoterResponse, error) {
+	if req.GetId() == "" || req.GetAddress() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id and address are required")
+	}
+
+	client, fctx, err := s.forwarder.LeaderClient(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	if client != nil {
+		return client.AddVoter(fctx, req)
+	}
+
+	if err := s.clusterService.AddVoter(ctx, req.GetId(), req.GetAddress()); err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &types.AddVoterResponse{}, nil
+}
+
+// AddNonvoter provides gRPC API for operators to add a non-voting server into the cluster.
+func (s *CrondGRPCService) AddNonvoter(ctx context.Context, req *types.AddNonvoterRequest) (*types.AddNonvoterResponse, error) {
+	if req.GetId() == "" || req.GetAddress() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id and address are required")
+	}
+
+	client, fctx, err := s.forwarder.LeaderClient(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	if client != nil {
+		return client.AddNonvoter(fctx, req)
+	}
+
+	if err := s.clusterService.AddNonvoter(ctx, req.GetId(), req.GetAddress()); err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &types.AddNonvoterResponse{}, nil
+}
+
+// RemoveServer provides gRPC API for operators to remove a server from the cluster.
+func (s *CrondGRPCService) RemoveServer(ctx context.Context, req *types.RemoveServerRequest) (*types.RemoveServerResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	client, fctx, err := s.forwarder.LeaderClient(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	if client != nil {
+		return client.RemoveServer(fctx, req)
+	}
+
+	if err := s.clusterService.RemoveServer(ctx, req.GetId()); err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &types.RemoveServerResponse{}, nil
+}
+
+// ListPeers provides gRPC API for operators to browse servers in the cluster.
+func (s *CrondGRPCService) ListPeers(ctx context.Context, req *types.ListPeersRequest) (*types.ListPeersResponse, error) {
+	peers, err := s.clusterService.ListPeers(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	resp := &types.ListPeersResponse{}
+	for _, peer := range peers {
+		resp.Peers = append(resp.Peers, peer.ToProto())
+	}
+
+	return resp, nil
+}
+
+// GetClusterStatus provides gRPC API for operators to inspect the current node, it is served locally.
+func (s *CrondGRPCService) GetClusterStatus(ctx context.Context,
+	req *types.GetClusterStatusRequest) (*types.GetClusterStatusResponse, error) {
+	status, err := s.clusterService.Status(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &types.GetClusterStatusResponse{Status: status.ToProto()}, nil
+}
+
+// toGRPCError converts crond internal errors into gRPC status errors.
+func toGRPCError(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidJob), errors.Is(err, ErrInvalidPageToken), errors.Is(err, ErrInvalidLabelSelector):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrJobNotFound), errors.Is(err, ErrJobRunNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrJobAlreadyExists), errors.Is(err, ErrJobKeyConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, ErrRevisionCompacted):
+		return status.Error(codes.OutOfRange, err.Error())
+	case errors.Is(err, ErrWatcherTooSlow):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, raft.ErrNotLeader), errors.Is(err, raft.ErrLeadershipLost), errors.Is(err, ErrNoLeader),
+		errors.Is(err, ErrDispatcherNotStarted), errors.Is(err, ErrWatchClosed), errors.Is(err, ErrNotCaughtUp):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
 }
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KevinWu0904/crond/proto/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSendEventsEndsWithStopReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		stop     func(hub *EventHub, w *Watcher)
+		wantCode codes.Code
+	}{
+		{name: "watcher closed", stop: func(hub *EventHub, w *Watcher) { w.Close() }, wantCode: codes.OK},
+		{name: "hub closed", stop: func(hub *EventHub, w *Watcher) { hub.Close() }, wantCode: codes.Unavailable},
+		{name: "hub reset", stop: func(hub *EventHub, w *Watcher) { hub.Reset(10) }, wantCode: codes.OutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewEventHub(10)
+			w, err := hub.Watch(0, func(event *JobEvent) bool { return true })
+			if err != nil {
+				t.Fatalf("Watch failed: err=%v", err)
+			}
+
+			publishEvents(hub, 1, 2)
+			tt.stop(hub, w)
+
+			var sent int
+			err = sendEvents(context.Background(), w, func(*types.WatchEvent) error {
+				sent++
+				return nil
+			})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("sendEvents() err = %v, want code %v", err, tt.wantCode)
+			}
+			if sent != 2 {
+				t.Errorf("sendEvents() sent %d events, want 2", sent)
+			}
+		})
+	}
+}
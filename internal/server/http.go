@@ -1,37 +1,580 @@
 package server
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KevinWu0904/crond/proto/types"
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
+	"github.com/hashicorp/raft"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// watchHeartbeatInterval is the interval of comments sent on idle watch streams, so proxies do not drop them.
+const watchHeartbeatInterval = time.Second * 15
+
+// protoMarshaler renders proto messages with the original proto field names, so JSON bodies mirror crond.proto.
+var protoMarshaler = protojson.MarshalOptions{UseProtoNames: true}
+
 // CrondHTTPService serves crond HTTP/1.x protocol APIs.
 type CrondHTTPService struct {
+	jobService     *JobService
+	clusterService *ClusterService
+	raftLayer      *RaftLayer
+	gatherer       prometheus.Gatherer // Gathers metrics exposed on /metrics.
 }
 
 // NewCrondHTTPService creates CrondHTTPService.
-func NewCrondHTTPService() *CrondHTTPService {
-	return &CrondHTTPService{}
+func NewCrondHTTPService(jobService *JobService, clusterService *ClusterService, raftLayer *RaftLayer,
+	gatherer prometheus.Gatherer) *CrondHTTPService {
+	return &CrondHTTPService{
+		jobService:     jobService,
+		clusterService: clusterService,
+		raftLayer:      raftLayer,
+		gatherer:       gatherer,
+	}
+}
+
+// RedirectToLeader redirects write requests from followers to the current raft leader.
+// Raft transport shares the same cmux port with HTTP, so the raft leader address is also the HTTP endpoint.
+func (hs *CrondHTTPService) RedirectToLeader(c *gin.Context) {
+	if hs.raftLayer.IsLeader() {
+		c.Next()
+		return
+	}
+
+	addr := hs.raftLayer.LeaderAddr()
+	if addr == "" {
+		renderError(c, http.StatusServiceUnavailable, ErrNoLeader)
+		c.Abort()
+		return
+	}
+
+	c.Redirect(http.StatusTemporaryRedirect, "http://"+string(addr)+c.Request.URL.RequestURI())
+	c.Abort()
 }
 
 // CreateJob provides HTTP API for users to create a job.
 func (hs *CrondHTTPService) CreateJob(c *gin.Context) {
-	panic("implement me")
+	var pb types.Job
+	if err := bindProto(c, &pb); err != nil {
+		renderError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	job, err := hs.jobService.CreateJob(c.Request.Context(), NewJobFromProto(&pb))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	renderProto(c, http.StatusCreated, job.ToProto())
 }
 
 // DeleteJob provides HTTP API for users to delete a job.
 func (hs *CrondHTTPService) DeleteJob(c *gin.Context) {
-	panic("implement me")
+	if _, err := hs.jobService.DeleteJob(c.Request.Context(), c.Param("job_id")); err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
 }
 
 // GetJob provides HTTP API for users to get a job.
 func (hs *CrondHTTPService) GetJob(c *gin.Context) {
-	panic("implement me")
+	job, err := hs.jobService.GetJob(c.Request.Context(), c.Param("job_id"))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	renderProto(c, http.StatusOK, job.ToProto())
+}
+
+// ListJobs provides HTTP API for users to browse jobs, enum query parameters accept both names and numbers.
+func (hs *CrondHTTPService) ListJobs(c *gin.Context) {
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "0"))
+	if err != nil {
+		renderError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	executorType, err := queryEnum(c, "executor_type", types.ExecutorType_value)
+	if err != nil {
+		renderError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	orderBy, err := queryEnum(c, "order_by", types.JobOrderBy_value)
+	if err != nil {
+		renderError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	descending, err := strconv.ParseBool(c.DefaultQuery("descending", "false"))
+	if err != nil {
+		renderError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	selector, err := ParseLabelSelector(c.Query("label_selector"))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	filter := &JobFilter{
+		Namespace:    c.Query("namespace"),
+		KeyPrefix:    c.Query("key_prefix"),
+		ExecutorType: ExecutorType(executorType),
+		Selector:     selector,
+	}
+	if value, ok := c.GetQuery("paused"); ok {
+		paused, err := strconv.ParseBool(value)
+		if err != nil {
+			renderError(c, http.StatusBadRequest, err)
+			return
+		}
+		filter.Paused = &paused
+	}
+
+	jobs, nextPageToken, err := hs.jobService.ListJobs(c.Request.Context(), filter, JobOrderBy(orderBy), descending,
+		pageSize, c.Query("page_token"))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	resp := &types.ListJobsResponse{NextPageToken: nextPageToken}
+	for _, job := range jobs {
+		resp.Jobs = append(resp.Jobs, job.ToProto())
+	}
+
+	renderProto(c, http.StatusOK, resp)
 }
 
 // UpdateJob provides HTTP API for users to update a job.
 func (hs *CrondHTTPService) UpdateJob(c *gin.Context) {
-	panic("implement me")
+	var pb types.Job
+	if err := bindProto(c, &pb); err != nil {
+		renderError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	jobID := c.Param("job_id")
+	if pb.GetJobId() != "" && pb.GetJobId() != jobID {
+		renderError(c, http.StatusBadRequest, errors.New("job_id in body mismatches job_id in path"))
+		return
+	}
+	pb.JobId = jobID
+
+	job, err := hs.jobService.UpdateJob(c.Request.Context(), NewJobFromProto(&pb))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	renderProto(c, http.StatusOK, job.ToProto())
+}
+
+// DeleteJobs provides HTTP API for users to delete jobs selected by labels in a namespace.
+func (hs *CrondHTTPService) DeleteJobs(c *gin.Context) {
+	namespace := c.Query("namespace")
+	if namespace == "" {
+		renderError(c, http.StatusBadRequest, errors.New("namespace is required"))
+		return
+	}
+
+	selector, err := ParseLabelSelector(c.Query("label_selector"))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	jobs, err := hs.jobService.DeleteJobs(c.Request.Context(), &JobFilter{Namespace: namespace, Selector: selector})
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	resp := &types.DeleteJobsResponse{}
+	for _, job := range jobs {
+		resp.Jobs = append(resp.Jobs, job.ToProto())
+	}
+
+	renderProto(c, http.StatusOK, resp)
+}
+
+// PauseJob provides HTTP API for users to pause a job.
+func (hs *CrondHTTPService) PauseJob(c *gin.Context) {
+	job, err := hs.jobService.PauseJob(c.Request.Context(), c.Param("job_id"))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	renderProto(c, http.StatusOK, job.ToProto())
+}
+
+// ResumeJob provides HTTP API for users to resume a paused job.
+func (hs *CrondHTTPService) ResumeJob(c *gin.Context) {
+	job, err := hs.jobService.ResumeJob(c.Request.Context(), c.Param("job_id"))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	renderProto(c, http.StatusOK, job.ToProto())
+}
+
+// TriggerJob provides HTTP API for users to run a job on demand, the started run is returned.
+func (hs *CrondHTTPService) TriggerJob(c *gin.Context) {
+	run, err := hs.jobService.TriggerJob(c.Request.Context(), c.Param("job_id"))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	renderProto(c, http.StatusAccepted, run.ToProto())
+}
+
+// GetJobRun provides HTTP API for users to get a run in run history.
+func (hs *CrondHTTPService) GetJobRun(c *gin.Context) {
+	run, err := hs.jobService.GetJobRun(c.Request.Context(), c.Param("job_id"), c.Param("run_id"))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	renderProto(c, http.StatusOK, run.ToProto())
+}
+
+// ListJobRuns provides HTTP API for users to browse run history of a job.
+func (hs *CrondHTTPService) ListJobRuns(c *gin.Context) {
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "0"))
+	if err != nil {
+		renderError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	runs, nextPageToken, err := hs.jobService.ListJobRuns(c.Request.Context(), c.Param("job_id"), pageSize,
+		c.Query("page_token"))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	resp := &types.ListJobRunsResponse{NextPageToken: nextPageToken}
+	for _, run := range runs {
+		resp.Runs = append(resp.Runs, run.ToProto())
+	}
+
+	renderProto(c, http.StatusOK, resp)
+}
+
+// ScheduleMethod dispatches custom methods on schedules, e.g. POST /v1/schedules:preview.
+func (hs *CrondHTTPService) ScheduleMethod(c *gin.Context) {
+	switch c.Param("method") {
+	case ":preview":
+		hs.PreviewSchedule(c)
+	default:
+		renderError(c, http.StatusNotFound, errors.New("unknown schedule method"))
+	}
+}
+
+// PreviewSchedule provides HTTP API for users to validate a schedule and preview its next fire times, a malformed
+// schedule is reported in the response body with 200.
+func (hs *CrondHTTPService) PreviewSchedule(c *gin.Context) {
+	var req types.PreviewScheduleRequest
+	if err := bindProto(c, &req); err != nil {
+		renderError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	start := time.Now()
+	if req.GetStartTime() != nil {
+		start = req.GetStartTime().AsTime()
+	}
+
+	times, err := hs.jobService.PreviewSchedule(c.Request.Context(), req.GetCronExpression(), req.GetTimeZone(),
+		start, int(req.GetCount()))
+	var scheduleErr *ScheduleError
+	if errors.As(err, &scheduleErr) {
+		renderProto(c, http.StatusOK, &types.PreviewScheduleResponse{Error: scheduleErr.ToProto()})
+		return
+	}
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	resp := &types.PreviewScheduleResponse{}
+	for _, t := range times {
+		resp.NextFireTimes = append(resp.NextFireTimes, timestamppb.New(t))
+	}
+
+	renderProto(c, http.StatusOK, resp)
+}
+
+// WatchJobs provides HTTP API for users to stream job events as server-sent events. The event id is the revision, so
+// reconnecting clients resume by Last-Event-ID header, or by revision query parameter.
+func (hs *CrondHTTPService) WatchJobs(c *gin.Context) {
+	revision, err := watchRevision(c)
+	if err != nil {
+		renderError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	selector, err := ParseLabelSelector(c.Query("label_selector"))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	filter := &JobFilter{Namespace: c.Query("namespace"), Selector: selector}
+	watcher, err := hs.jobService.WatchJobs(c.Request.Context(), revision, filter, c.Query("job_id"))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+	defer watcher.Close()
+
+	streamEvents(c, watcher)
+}
+
+// WatchRuns provides HTTP API for users to stream run events as server-sent events, it resumes like WatchJobs.
+func (hs *CrondHTTPService) WatchRuns(c *gin.Context) {
+	revision, err := watchRevision(c)
+	if err != nil {
+		renderError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	watcher, err := hs.jobService.WatchRuns(c.Request.Context(), revision, c.Query("namespace"), c.Query("job_id"))
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+	defer watcher.Close()
+
+	streamEvents(c, watcher)
+}
+
+// AddPeer provides HTTP API for operators to add a server into the cluster, it is added as a voter unless nonvoter
+// query parameter is true.
+func (hs *CrondHTTPService) AddPeer(c *gin.Context) {
+	var pb types.Peer
+	if err := bindProto(c, &pb); err != nil {
+		renderError(c, http.StatusBadRequest, err)
+		return
+	}
+	if pb.GetId() == "" || pb.GetAddress() == "" {
+		renderError(c, http.StatusBadRequest, errors.New("id and address are required"))
+		return
+	}
+
+	nonvoter, err := strconv.ParseBool(c.DefaultQuery("nonvoter", "false"))
+	if err != nil {
+		renderError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if nonvoter {
+		err = hs.clusterService.AddNonvoter(c.Request.Context(), pb.GetId(), pb.GetAddress())
+	} else {
+		err = hs.clusterService.AddVoter(c.Request.Context(), pb.GetId(), pb.GetAddress())
+	}
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemovePeer provides HTTP API for operators to remove a server from the cluster.
+func (hs *CrondHTTPService) RemovePeer(c *gin.Context) {
+	if err := hs.clusterService.RemoveServer(c.Request.Context(), c.Param("id")); err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListPeers provides HTTP API for operators to browse servers in the cluster.
+func (hs *CrondHTTPService) ListPeers(c *gin.Context) {
+	peers, err := hs.clusterService.ListPeers(c.Request.Context())
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	resp := &types.ListPeersResponse{}
+	for _, peer := range peers {
+		resp.Peers = append(resp.Peers, peer.ToProto())
+	}
+
+	renderProto(c, http.StatusOK, resp)
+}
+
+// GetClusterStatus provides HTTP API for operators to inspect the current node, it is served locally.
+func (hs *CrondHTTPService) GetClusterStatus(c *gin.Context) {
+	status, err := hs.clusterService.Status(c.Request.Context())
+	if err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	renderProto(c, http.StatusOK, status.ToProto())
+}
+
+// Healthz provides HTTP liveness probe, it succeeds as long as the server is serving.
+func (hs *CrondHTTPService) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz provides HTTP readiness probe, it succeeds only if the current node knows raft leader and has applied all
+// committed logs.
+func (hs *CrondHTTPService) Readyz(c *gin.Context) {
+	if err := hs.clusterService.Ready(c.Request.Context()); err != nil {
+		renderError(c, toHTTPStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// watchRevision returns the revision to resume after, Last-Event-ID header sent by reconnecting EventSource takes
+// precedence over revision query parameter.
+func watchRevision(c *gin.Context) (uint64, error) {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.DefaultQuery("revision", "0")
+	}
+
+	revision, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid revision: %s", value)
+	}
+
+	return revision, nil
+}
+
+// streamEvents writes events of the watcher as server-sent events until the client goes away or the watcher stops,
+// the reason is sent as an error event then. Comments are sent periodically to keep idle connections alive.
+func streamEvents(c *gin.Context, watcher *Watcher) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(watchHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case event, ok := <-watcher.Events():
+			if !ok {
+				// Err is nil when the watcher was closed by its owner, there is no reason to send then.
+				if err := watcher.Err(); err != nil {
+					fmt.Fprintf(c.Writer, "event: error\ndata: {\"error\":%q}\n\n", err)
+					c.Writer.Flush()
+				}
+				return
+			}
+
+			data, err := protoMarshaler.Marshal(event.ToProto())
+			if err != nil {
+				fmt.Fprintf(c.Writer, "event: error\ndata: {\"error\":%q}\n\n", err)
+				c.Writer.Flush()
+				return
+			}
+			fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Revision, event.Type, data)
+		}
+		c.Writer.Flush()
+	}
+}
+
+// queryEnum parses an enum query parameter given by name or number, a missing parameter is parsed as zero.
+func queryEnum(c *gin.Context, key string, values map[string]int32) (int32, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	if number, ok := values[value]; ok {
+		return number, nil
+	}
+
+	number, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", key, value)
+	}
+
+	return int32(number), nil
+}
+
+// bindProto decodes the JSON request body into a proto message.
+func bindProto(c *gin.Context, m proto.Message) error {
+	data, err := c.GetRawData()
+	if err != nil {
+		return err
+	}
+
+	return protojson.Unmarshal(data, m)
+}
+
+// renderProto writes a proto message as JSON response.
+func renderProto(c *gin.Context, code int, m proto.Message) {
+	data, err := protoMarshaler.Marshal(m)
+	if err != nil {
+		renderError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Data(code, "application/json; charset=utf-8", data)
+}
+
+// renderError writes an error as JSON response.
+func renderError(c *gin.Context, code int, err error) {
+	c.JSON(code, gin.H{"error": err.Error()})
+}
+
+// toHTTPStatus converts crond internal errors into HTTP status codes.
+func toHTTPStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidJob), errors.Is(err, ErrInvalidPageToken), errors.Is(err, ErrInvalidLabelSelector):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrJobNotFound), errors.Is(err, ErrJobRunNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrJobAlreadyExists), errors.Is(err, ErrJobKeyConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrRevisionCompacted):
+		return http.StatusGone
+	case errors.Is(err, ErrWatcherTooSlow):
+		return http.StatusTooManyRequests
+	case errors.Is(err, raft.ErrNotLeader), errors.Is(err, raft.ErrLeadershipLost), errors.Is(err, ErrNoLeader),
+		errors.Is(err, ErrDispatcherNotStarted), errors.Is(err, ErrWatchClosed), errors.Is(err, ErrNotCaughtUp):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // RegisterCrondHTTPServer registers crond HTTP routers.
@@ -39,14 +582,44 @@ func (hs *CrondHTTPService) UpdateJob(c *gin.Context) {
 func RegisterCrondHTTPServer(r *gin.Engine, server *CrondHTTPService) {
 	pprof.Register(r)
 
-	v1 := r.Group("/v1")
+	r.GET("/healthz", server.Healthz)
+	r.GET("/readyz", server.Readyz)
+	r.GET("/metrics", gin.WrapH(promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(server.gatherer, promhttp.HandlerOpts{}))))
+
+	// Probes and metrics scrapes are not traced.
+	v1 := r.Group("/v1", otelgin.Middleware(tracerServiceName), HTTPTraceKVs)
 	{
 		jobs := v1.Group("/jobs")
 		{
-			jobs.POST("", server.CreateJob)
-			jobs.DELETE("/:job_id", server.DeleteJob)
+			jobs.POST("", server.RedirectToLeader, server.CreateJob)
+			jobs.GET("", server.ListJobs)
+			jobs.DELETE("", server.RedirectToLeader, server.DeleteJobs)
+			jobs.DELETE("/:job_id", server.RedirectToLeader, server.DeleteJob)
 			jobs.GET("/:job_id", server.GetJob)
-			jobs.PUT("/:job_id", server.UpdateJob)
+			jobs.PUT("/:job_id", server.RedirectToLeader, server.UpdateJob)
+			jobs.POST("/:job_id/pause", server.RedirectToLeader, server.PauseJob)
+			jobs.POST("/:job_id/resume", server.RedirectToLeader, server.ResumeJob)
+			jobs.POST("/:job_id/trigger", server.RedirectToLeader, server.TriggerJob)
+			jobs.GET("/:job_id/runs", server.ListJobRuns)
+			jobs.GET("/:job_id/runs/:run_id", server.GetJobRun)
 		}
+
+		cluster := v1.Group("/cluster")
+		{
+			cluster.GET("/peers", server.ListPeers)
+			cluster.POST("/peers", server.RedirectToLeader, server.AddPeer)
+			cluster.DELETE("/peers/:id", server.RedirectToLeader, server.RemovePeer)
+			cluster.GET("/status", server.GetClusterStatus)
+		}
+
+		watch := v1.Group("/watch")
+		{
+			watch.GET("/jobs", server.WatchJobs)
+			watch.GET("/runs", server.WatchRuns)
+		}
+
+		// gin routes "/schedules:method" as a wildcard starting after "/schedules", custom methods are dispatched by it.
+		v1.POST("/schedules:method", server.ScheduleMethod)
 	}
 }
@@ -1,18 +1,420 @@
 package server
 
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/KevinWu0904/crond/pkg/logs"
+	"github.com/KevinWu0904/crond/proto/types"
+	"github.com/robfig/cron/v3"
+)
+
+// ErrInvalidJob throws when the job fails validation.
+var ErrInvalidJob = errors.New("invalid job")
+
+// maxDurationSeconds is the largest number of whole seconds time.Duration can hold.
+const maxDurationSeconds = math.MaxInt64 / int64(time.Second)
+
+// ConcurrencyPolicy decides what CronDispatcher does when a job fires while its previous run is still running.
+type ConcurrencyPolicy int8
+
+const (
+	// ConcurrencyPolicyAllow runs the new run concurrently.
+	ConcurrencyPolicyAllow ConcurrencyPolicy = iota
+	// ConcurrencyPolicyForbid skips the new run and records it as skipped.
+	ConcurrencyPolicyForbid
+	// ConcurrencyPolicyReplace cancels the running runs and starts the new run.
+	ConcurrencyPolicyReplace
+)
+
+// ScheduleKind defines how a job is scheduled.
+type ScheduleKind int8
+
+const (
+	// ScheduleKindCron fires by CronExpression in TimeZone, which defaults to UTC.
+	ScheduleKindCron ScheduleKind = iota
+	// ScheduleKindOnce fires once at RunAt.
+	ScheduleKindOnce
+	// ScheduleKindDelay fires once after Delay since the job is stored, JobService derives RunAt from it.
+	ScheduleKindDelay
+	// ScheduleKindInterval fires every Interval since AnchorTime, which defaults to the time the job is stored.
+	ScheduleKindInterval
+)
+
 // Job represents crond Job entity in memory.
 type Job struct {
-	JobID          string
-	JobKey         string
-	JobDisplayName string
-	CronExpression string
-	ExecutorType   ExecutorType
+	JobID          string        `json:"job_id"`
+	Namespace      string        `json:"namespace,omitempty"`
+	JobKey         string        `json:"job_key"`
+	JobDisplayName string        `json:"job_display_name"`
+	CronExpression string        `json:"cron_expression"`
+	TimeZone       string        `json:"time_zone,omitempty"`
+	ExecutorType   ExecutorType  `json:"executor_type"`
+	Timeout        time.Duration `json:"timeout"`
+	RetryPolicy    *RetryPolicy  `json:"retry_policy,omitempty"`
+
+	// Schedules other than ScheduleKindCron, times are in whole seconds. EndTime bounds repeating schedules.
+	ScheduleKind ScheduleKind  `json:"schedule_kind,omitempty"`
+	RunAt        time.Time     `json:"run_at"`
+	Delay        time.Duration `json:"delay,omitempty"`
+	Interval     time.Duration `json:"interval,omitempty"`
+	AnchorTime   time.Time     `json:"anchor_time"`
+	EndTime      time.Time     `json:"end_time"`
+
+	// Labels organize jobs together with Namespace, they can be selected by LabelSelector.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrency_policy,omitempty"`
+	MisfirePolicy     *MisfirePolicy    `json:"misfire_policy,omitempty"`
+
+	// Paused jobs are not dispatched by schedule, but they can still be triggered manually.
+	Paused bool `json:"paused,omitempty"`
+
+	// UpdateTime is stamped by JobService, LastScheduledTime is maintained by JobFSM with the latest scheduled run.
+	// Together they tell CronDispatcher since when fires may have been missed.
+	UpdateTime        time.Time `json:"update_time"`
+	LastScheduledTime time.Time `json:"last_scheduled_time"`
+
+	// CompleteTime is set by CronDispatcher through JobFSM after the final run of an exhausted schedule, completed
+	// jobs are no longer dispatched and they are deleted once expired.
+	CompleteTime time.Time `json:"complete_time"`
+
+	// Executor specific configurations, only the one matches ExecutorType takes effect.
+	Shell *ShellConfig `json:"shell,omitempty"`
+	HTTP  *HTTPConfig  `json:"http,omitempty"`
+	GRPC  *GRPCConfig  `json:"grpc,omitempty"`
+}
+
+// NewJobFromProto converts types.Job into Job.
+func NewJobFromProto(pb *types.Job) *Job {
+	return &Job{
+		JobID:             pb.GetJobId(),
+		Namespace:         pb.GetNamespace(),
+		JobKey:            pb.GetJobKey(),
+		JobDisplayName:    pb.GetJobDisplayName(),
+		CronExpression:    pb.GetCronExpression(),
+		TimeZone:          pb.GetTimeZone(),
+		ScheduleKind:      ScheduleKind(pb.GetScheduleKind()),
+		RunAt:             fromProtoTime(pb.GetRunAt()),
+		Delay:             fromProtoSeconds(pb.GetDelaySeconds()),
+		Interval:          fromProtoSeconds(pb.GetIntervalSeconds()),
+		AnchorTime:        fromProtoTime(pb.GetAnchorTime()),
+		EndTime:           fromProtoTime(pb.GetEndTime()),
+		Labels:            pb.GetLabels(),
+		ExecutorType:      ExecutorType(pb.GetExecutorType()),
+		Timeout:           fromProtoSeconds(pb.GetTimeoutSeconds()),
+		RetryPolicy:       NewRetryPolicyFromProto(pb.GetRetryPolicy()),
+		ConcurrencyPolicy: ConcurrencyPolicy(pb.GetConcurrencyPolicy()),
+		MisfirePolicy:     NewMisfirePolicyFromProto(pb.GetMisfirePolicy()),
+		Paused:            pb.GetPaused(),
+		Shell:             NewShellConfigFromProto(pb.GetShell()),
+		HTTP:              NewHTTPConfigFromProto(pb.GetHttp()),
+		GRPC:              NewGRPCConfigFromProto(pb.GetGrpc()),
+	}
+}
+
+// fromProtoSeconds converts whole seconds into time.Duration. Out of range seconds saturate instead of wrapping
+// around, so they are rejected by Validate.
+func fromProtoSeconds(seconds int64) time.Duration {
+	switch {
+	case seconds > maxDurationSeconds:
+		return math.MaxInt64
+	case seconds < -maxDurationSeconds:
+		return math.MinInt64
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// ToProto converts Job into types.Job.
+func (j *Job) ToProto() *types.Job {
+	pb := &types.Job{
+		JobId:             j.JobID,
+		Namespace:         j.Namespace,
+		JobKey:            j.JobKey,
+		JobDisplayName:    j.JobDisplayName,
+		CronExpression:    j.CronExpression,
+		TimeZone:          j.TimeZone,
+		ScheduleKind:      types.ScheduleKind(j.ScheduleKind),
+		RunAt:             toProtoTime(j.RunAt),
+		DelaySeconds:      int64(j.Delay / time.Second),
+		IntervalSeconds:   int64(j.Interval / time.Second),
+		AnchorTime:        toProtoTime(j.AnchorTime),
+		EndTime:           toProtoTime(j.EndTime),
+		Labels:            j.Labels,
+		ExecutorType:      types.ExecutorType(j.ExecutorType),
+		TimeoutSeconds:    int64(j.Timeout / time.Second),
+		ConcurrencyPolicy: types.ConcurrencyPolicy(j.ConcurrencyPolicy),
+		UpdateTime:        toProtoTime(j.UpdateTime),
+		LastScheduledTime: toProtoTime(j.LastScheduledTime),
+		Paused:            j.Paused,
+		NextRunTime:       toProtoTime(j.NextRunTime(time.Now())),
+		CompleteTime:      toProtoTime(j.CompleteTime),
+	}
+
+	if j.RetryPolicy != nil {
+		pb.RetryPolicy = j.RetryPolicy.ToProto()
+	}
+	if j.MisfirePolicy != nil {
+		pb.MisfirePolicy = j.MisfirePolicy.ToProto()
+	}
+
+	switch {
+	case j.Shell != nil:
+		pb.ExecutorConfig = &types.Job_Shell{Shell: j.Shell.ToProto()}
+	case j.HTTP != nil:
+		pb.ExecutorConfig = &types.Job_Http{Http: j.HTTP.ToProto()}
+	case j.GRPC != nil:
+		pb.ExecutorConfig = &types.Job_Grpc{Grpc: j.GRPC.ToProto()}
+	}
+
+	return pb
+}
+
+// NewJobID generates a random JobID.
+func NewJobID() string {
+	return newRandomID()
+}
+
+func newRandomID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// Validate checks whether the job can be accepted by CronDispatcher.
+func (j *Job) Validate() error {
+	if j.Namespace != "" {
+		if err := validateNamespace(j.Namespace); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidJob, err)
+		}
+	}
+
+	if j.JobKey == "" {
+		return fmt.Errorf("%w: job_key is required", ErrInvalidJob)
+	}
+
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{{"delay_seconds", j.Delay}, {"interval_seconds", j.Interval}, {"timeout_seconds", j.Timeout}} {
+		if d.value > time.Duration(maxDurationSeconds)*time.Second {
+			return fmt.Errorf("%w: %s must not exceed %d", ErrInvalidJob, d.name, maxDurationSeconds)
+		}
+	}
+
+	if err := validateLabels(j.Labels); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJob, err)
+	}
+
+	if err := j.validateSchedule(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJob, err)
+	}
+
+	if j.Timeout < 0 {
+		return fmt.Errorf("%w: timeout_seconds must not be negative", ErrInvalidJob)
+	}
+
+	if j.ConcurrencyPolicy < ConcurrencyPolicyAllow || j.ConcurrencyPolicy > ConcurrencyPolicyReplace {
+		return fmt.Errorf("%w: concurrency_policy %d is not supported", ErrInvalidJob, j.ConcurrencyPolicy)
+	}
+
+	if j.RetryPolicy != nil {
+		if err := j.RetryPolicy.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidJob, err)
+		}
+	}
+
+	if j.MisfirePolicy != nil {
+		if err := j.MisfirePolicy.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidJob, err)
+		}
+	}
+
+	if j.ExecutorType == ExecutorTypeUnspecified {
+		return fmt.Errorf("%w: executor_type is required", ErrInvalidJob)
+	}
+
+	executor, err := GetExecutor(j.ExecutorType)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJob, err)
+	}
+
+	if err := executor.Validate(j); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJob, err)
+	}
+
+	return nil
+}
+
+func (j *Job) validateSchedule() error {
+	if j.ScheduleKind != ScheduleKindCron && (j.CronExpression != "" || j.TimeZone != "") {
+		return errors.New("cron_expression and time_zone only apply to cron schedules")
+	}
+
+	switch j.ScheduleKind {
+	case ScheduleKindCron:
+		if _, err := j.Schedule(); err != nil {
+			return fmt.Errorf("cron_expression %q is malformed: %v", j.CronExpression, err)
+		}
+	case ScheduleKindOnce:
+		if j.RunAt.IsZero() {
+			return errors.New("run_at is required")
+		}
+	case ScheduleKindDelay:
+		if j.Delay <= 0 {
+			return errors.New("delay_seconds must be positive")
+		}
+	case ScheduleKindInterval:
+		if j.Interval <= 0 {
+			return errors.New("interval_seconds must be positive")
+		}
+	default:
+		return fmt.Errorf("schedule_kind %d is not supported", j.ScheduleKind)
+	}
+
+	if _, ok := j.oneShotTime(); ok && !j.EndTime.IsZero() {
+		return errors.New("end_time only applies to repeating schedules")
+	}
+
+	return nil
+}
+
+// resolveSchedule fixes the schedule when the job is stored at now: a delay starts from now and an interval is
+// anchored at now by default. Times are truncated to whole seconds, at which CronDispatcher fires.
+func (j *Job) resolveSchedule(now time.Time) {
+	switch j.ScheduleKind {
+	case ScheduleKindDelay:
+		j.RunAt = now.Add(j.Delay)
+	case ScheduleKindInterval:
+		if j.AnchorTime.IsZero() {
+			j.AnchorTime = now
+		}
+	}
+
+	j.RunAt = j.RunAt.Truncate(time.Second)
+	j.AnchorTime = j.AnchorTime.Truncate(time.Second)
+	j.EndTime = j.EndTime.Truncate(time.Second)
+}
+
+// Schedule returns the schedule of ScheduleKind bounded by EndTime. Cron schedules parse CronExpression in TimeZone,
+// see wallClockSchedule for the behavior at DST transitions.
+func (j *Job) Schedule() (cron.Schedule, error) {
+	var schedule cron.Schedule
+	switch j.ScheduleKind {
+	case ScheduleKindOnce, ScheduleKindDelay:
+		schedule = onceSchedule(j.RunAt)
+	case ScheduleKindInterval:
+		schedule = &intervalSchedule{anchor: j.AnchorTime, interval: j.Interval}
+	default:
+		var err error
+		if schedule, err = parseSchedule(j.CronExpression, j.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+
+	if !j.EndTime.IsZero() {
+		schedule = &endingSchedule{schedule: schedule, end: j.EndTime}
+	}
+
+	return schedule, nil
+}
+
+// oneShotTime returns the only scheduled time of a job firing once.
+func (j *Job) oneShotTime() (time.Time, bool) {
+	if j.ScheduleKind == ScheduleKindOnce || j.ScheduleKind == ScheduleKindDelay {
+		return j.RunAt, true
+	}
+
+	return time.Time{}, false
+}
+
+// exhausted reports whether the schedule has no fire after t, a malformed schedule is never exhausted. JobFSM relies
+// on it in apply, it does not depend on the node since the schedule never resolves a zone from the node.
+func (j *Job) exhausted(t time.Time) bool {
+	schedule, err := j.Schedule()
+	if err != nil {
+		return false
+	}
+
+	return schedule.Next(t).IsZero()
+}
+
+// NextRunTime returns the next scheduled time after now, it is zero if the job is paused or completed.
+func (j *Job) NextRunTime(now time.Time) time.Time {
+	if j.Paused || !j.CompleteTime.IsZero() {
+		return time.Time{}
+	}
+
+	schedule, err := j.Schedule()
+	if err != nil {
+		return time.Time{}
+	}
+
+	return schedule.Next(now)
+}
+
+func (j *Job) namespacedKey() namespacedKey {
+	return namespacedKey{Namespace: j.Namespace, JobKey: j.JobKey}
 }
 
-// ExecutorType defines multiple executor types, different type will be running by different executors.
-type ExecutorType int8
+// Clone returns a deep copy of Job.
+func (j *Job) Clone() *Job {
+	clone := *j
+	if j.Labels != nil {
+		clone.Labels = make(map[string]string, len(j.Labels))
+		for k, v := range j.Labels {
+			clone.Labels[k] = v
+		}
+	}
+	if j.RetryPolicy != nil {
+		clone.RetryPolicy = j.RetryPolicy.Clone()
+	}
+	if j.MisfirePolicy != nil {
+		clone.MisfirePolicy = j.MisfirePolicy.Clone()
+	}
+	if j.Shell != nil {
+		clone.Shell = j.Shell.Clone()
+	}
+	if j.HTTP != nil {
+		clone.HTTP = j.HTTP.Clone()
+	}
+	if j.GRPC != nil {
+		clone.GRPC = j.GRPC.Clone()
+	}
+
+	return &clone
+}
+
+// Execute dispatches the job to its Executor, the execution will be canceled once ctx is done or timeout. ctx is
+// expected to carry the job key in logs context KVs already.
+func (j *Job) Execute(ctx context.Context) (*ExecutionResult, error) {
+	executor, err := GetExecutor(j.ExecutorType)
+	if err != nil {
+		logs.CtxError(ctx, "Execute failed to get executor: err=%v", err)
+		return nil, err
+	}
+
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
 
-// Run implements cron.Job interface.
-func (*Job) Run() {
+	result, err := executor.Execute(ctx, j)
+	if err != nil {
+		logs.CtxWarn(ctx, "Execute failed: err=%v", err)
+		return result, err
+	}
 
+	logs.CtxInfo(ctx, "Execute successfully: code=%d", result.Code)
+	return result, nil
 }
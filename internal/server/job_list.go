@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JobOrderBy defines the order of listed jobs, ties are broken by JobID.
+type JobOrderBy int8
+
+const (
+	// JobOrderByKey orders jobs by Namespace and JobKey.
+	JobOrderByKey JobOrderBy = iota
+	// JobOrderByName orders jobs by JobDisplayName.
+	JobOrderByName
+	// JobOrderByNextRunTime orders jobs by the next scheduled time, paused jobs come last.
+	JobOrderByNextRunTime
+)
+
+// JobFilter selects jobs, zero fields match all jobs.
+type JobFilter struct {
+	Namespace    string
+	KeyPrefix    string
+	ExecutorType ExecutorType
+	Paused       *bool
+	Selector     LabelSelector
+}
+
+// Match reports whether the job is selected by JobFilter.
+func (f *JobFilter) Match(job *Job) bool {
+	switch {
+	case f.Namespace != "" && job.Namespace != f.Namespace:
+		return false
+	case !strings.HasPrefix(job.JobKey, f.KeyPrefix):
+		return false
+	case f.ExecutorType != ExecutorTypeUnspecified && job.ExecutorType != f.ExecutorType:
+		return false
+	case f.Paused != nil && job.Paused != *f.Paused:
+		return false
+	case !f.Selector.Matches(job.Labels):
+		return false
+	}
+
+	return true
+}
+
+// jobSortKey is the position of a job in the listing order.
+type jobSortKey struct {
+	Key   string `json:"k,omitempty"`
+	Time  int64  `json:"t,omitempty"`
+	JobID string `json:"i"`
+}
+
+func newJobSortKey(job *Job, orderBy JobOrderBy, now time.Time) jobSortKey {
+	switch orderBy {
+	case JobOrderByName:
+		return jobSortKey{Key: job.JobDisplayName, JobID: job.JobID}
+	case JobOrderByNextRunTime:
+		next := job.NextRunTime(now)
+		if next.IsZero() {
+			return jobSortKey{Time: math.MaxInt64, JobID: job.JobID}
+		}
+		return jobSortKey{Time: next.UnixNano(), JobID: job.JobID}
+	default:
+		// Namespaces never contain NUL, so jobs are grouped by namespace.
+		return jobSortKey{Key: job.Namespace + "\x00" + job.JobKey, JobID: job.JobID}
+	}
+}
+
+func (k jobSortKey) less(other jobSortKey) bool {
+	if k.Key != other.Key {
+		return k.Key < other.Key
+	}
+	if k.Time != other.Time {
+		return k.Time < other.Time
+	}
+
+	return k.JobID < other.JobID
+}
+
+// jobCursor is the decoded page token of ListJobs, it holds the position of the last job in previous page, so
+// created and deleted jobs will not shift pages. Positions by next run time move as time goes by, so a job may be
+// listed twice or skipped across pages in that order.
+type jobCursor struct {
+	OrderBy    JobOrderBy `json:"o"`
+	Descending bool       `json:"d,omitempty"`
+	Last       jobSortKey `json:"l"`
+}
+
+func (c *jobCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeJobCursor(token string) (*jobCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	var c jobCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	return &c, nil
+}
+
+// sortJobs sorts jobs and returns their positions in the same order.
+func sortJobs(jobs []*Job, orderBy JobOrderBy, descending bool, now time.Time) []jobSortKey {
+	keys := make([]jobSortKey, len(jobs))
+	for i, job := range jobs {
+		keys[i] = newJobSortKey(job, orderBy, now)
+	}
+
+	sort.Sort(&jobSorter{jobs: jobs, keys: keys, descending: descending})
+	return keys
+}
+
+type jobSorter struct {
+	jobs       []*Job
+	keys       []jobSortKey
+	descending bool
+}
+
+func (s *jobSorter) Len() int {
+	return len(s.jobs)
+}
+
+func (s *jobSorter) Less(i, j int) bool {
+	if s.descending {
+		return s.keys[j].less(s.keys[i])
+	}
+
+	return s.keys[i].less(s.keys[j])
+}
+
+func (s *jobSorter) Swap(i, j int) {
+	s.jobs[i], s.jobs[j] = s.jobs[j], s.jobs[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
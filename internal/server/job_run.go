@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KevinWu0904/crond/proto/types"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// runMaxOutputSize is the maximum bytes of output persisted in run history, it is smaller than executors capture
+// limits because every run is replicated by raft.
+const runMaxOutputSize = 4 * 1024
+
+// errRunInterrupted marks runs which were still running when the previous leader stepped down.
+var errRunInterrupted = errors.New("run interrupted by raft leadership change")
+
+// JobRunStatus defines the lifecycle status of a single job execution.
+type JobRunStatus int8
+
+const (
+	// JobRunStatusUnspecified is the zero value of JobRunStatus.
+	JobRunStatusUnspecified JobRunStatus = iota
+	// JobRunStatusRunning means the run has started but not finished yet.
+	JobRunStatusRunning
+	// JobRunStatusSucceeded means the executor reported success.
+	JobRunStatusSucceeded
+	// JobRunStatusFailed means the executor reported failure.
+	JobRunStatusFailed
+	// JobRunStatusTimedOut means the run exceeded the job timeout.
+	JobRunStatusTimedOut
+	// JobRunStatusCanceled means the run was canceled, e.g. CronDispatcher stopped forcibly or a newer run replaced it.
+	JobRunStatusCanceled
+	// JobRunStatusSkipped means the run was not started because of ConcurrencyPolicyForbid.
+	JobRunStatusSkipped
+)
+
+// JobRunTrigger defines what started a run.
+type JobRunTrigger int8
+
+const (
+	// JobRunTriggerSchedule means the run was fired by the job schedule.
+	JobRunTriggerSchedule JobRunTrigger = iota
+	// JobRunTriggerMisfire means the run catches up a fire missed during leader failover.
+	JobRunTriggerMisfire
+	// JobRunTriggerManual means the run was triggered on demand by users.
+	JobRunTriggerManual
+)
+
+var jobRunStatusNames = map[JobRunStatus]string{
+	JobRunStatusUnspecified: "unspecified",
+	JobRunStatusRunning:     "running",
+	JobRunStatusSucceeded:   "succeeded",
+	JobRunStatusFailed:      "failed",
+	JobRunStatusTimedOut:    "timed_out",
+	JobRunStatusCanceled:    "canceled",
+	JobRunStatusSkipped:     "skipped",
+}
+
+// String implements fmt.Stringer interface.
+func (s JobRunStatus) String() string {
+	return jobRunStatusNames[s]
+}
+
+// Finished reports whether the run has reached a terminal status.
+func (s JobRunStatus) Finished() bool {
+	return s != JobRunStatusUnspecified && s != JobRunStatusRunning
+}
+
+// JobRun represents a single job execution record in run history.
+type JobRun struct {
+	RunID         string       `json:"run_id"`
+	JobID         string       `json:"job_id"`
+	ScheduledTime time.Time    `json:"scheduled_time"`
+	StartTime     time.Time    `json:"start_time"`
+	EndTime       time.Time    `json:"end_time"`
+	Node          string       `json:"node"`
+	Status        JobRunStatus `json:"status"`
+	Code          int32        `json:"code"`
+	Output        string       `json:"output,omitempty"`
+	Error         string       `json:"error,omitempty"`
+	Attempt       int32        `json:"attempt"`
+	FailureClass  FailureClass `json:"failure_class,omitempty"`
+	RetryOf       string       `json:"retry_of,omitempty"`
+	NextRetryTime time.Time    `json:"next_retry_time,omitempty"`
+
+	Trigger JobRunTrigger `json:"trigger,omitempty"`
+}
+
+// NewJobRun creates a running JobRun for the first attempt of the job scheduled at the specific time.
+func NewJobRun(job *Job, scheduledTime time.Time, node string) *JobRun {
+	return &JobRun{
+		RunID:         newRandomID(),
+		JobID:         job.JobID,
+		ScheduledTime: scheduledTime,
+		StartTime:     time.Now(),
+		Node:          node,
+		Status:        JobRunStatusRunning,
+		Attempt:       1,
+	}
+}
+
+// NewRetryJobRun creates a running JobRun which retries the failed run.
+func NewRetryJobRun(prev *JobRun, node string) *JobRun {
+	return &JobRun{
+		RunID:         newRandomID(),
+		JobID:         prev.JobID,
+		ScheduledTime: prev.ScheduledTime,
+		StartTime:     time.Now(),
+		Node:          node,
+		Status:        JobRunStatusRunning,
+		Attempt:       prev.Attempt + 1,
+		RetryOf:       prev.RunID,
+		Trigger:       prev.Trigger,
+	}
+}
+
+// Finish fills the run with the execution outcome.
+func (r *JobRun) Finish(result *ExecutionResult, err error) {
+	r.EndTime = time.Now()
+
+	if result != nil {
+		r.Code = result.Code
+		r.Output = truncate(result.Output, runMaxOutputSize)
+	}
+
+	switch {
+	case err == nil:
+		r.Status = JobRunStatusSucceeded
+	case errors.Is(err, context.DeadlineExceeded):
+		r.Status = JobRunStatusTimedOut
+		r.FailureClass = FailureClassTimedOut
+	case errors.Is(err, context.Canceled):
+		r.Status = JobRunStatusCanceled
+	case result == nil:
+		r.Status = JobRunStatusFailed
+		r.FailureClass = FailureClassError
+	default:
+		r.Status = JobRunStatusFailed
+		r.FailureClass = FailureClassFailed
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// Skip marks the run as skipped without execution.
+func (r *JobRun) Skip(reason string) {
+	r.EndTime = r.StartTime
+	r.Status = JobRunStatusSkipped
+	r.Error = reason
+}
+
+// Clone returns a deep copy of JobRun.
+func (r *JobRun) Clone() *JobRun {
+	clone := *r
+	return &clone
+}
+
+// ToProto converts JobRun into types.JobRun.
+func (r *JobRun) ToProto() *types.JobRun {
+	return &types.JobRun{
+		RunId:         r.RunID,
+		JobId:         r.JobID,
+		ScheduledTime: toProtoTime(r.ScheduledTime),
+		StartTime:     toProtoTime(r.StartTime),
+		EndTime:       toProtoTime(r.EndTime),
+		Node:          r.Node,
+		Status:        types.JobRunStatus(r.Status),
+		Code:          r.Code,
+		Output:        r.Output,
+		Error:         r.Error,
+		Attempt:       r.Attempt,
+		FailureClass:  types.FailureClass(r.FailureClass),
+		RetryOf:       r.RetryOf,
+		NextRetryTime: toProtoTime(r.NextRetryTime),
+		Trigger:       types.JobRunTrigger(r.Trigger),
+	}
+}
+
+// JobRunRecorder persists JobRun into run history.
+type JobRunRecorder interface {
+	RecordJobRun(ctx context.Context, run *JobRun) error
+}
+
+// toProtoTime converts time.Time into timestamppb.Timestamp, zero time is converted into nil.
+func toProtoTime(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
+
+// fromProtoTime converts timestamppb.Timestamp into time.Time, nil is converted into zero time.
+func fromProtoTime(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return t.AsTime()
+}
+
+// truncate keeps at most limit bytes of s.
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+
+	return s[:limit] + "...(truncated)"
+}
@@ -0,0 +1,320 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/KevinWu0904/crond/internal/common/constant"
+	"github.com/KevinWu0904/crond/pkg/logs"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	defaultPreviewCount = 10
+	maxPreviewCount     = 100
+)
+
+// ErrInvalidPageToken throws when the page token can not be recognized.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// JobService implements crond job management, it is shared by all protocol services.
+type JobService struct {
+	raftLayer  *RaftLayer
+	fsm        *JobFSM
+	events     *EventHub
+	dispatcher *CronDispatcher
+}
+
+// NewJobService creates JobService.
+func NewJobService(raftLayer *RaftLayer, fsm *JobFSM, events *EventHub) *JobService {
+	return &JobService{
+		raftLayer: raftLayer,
+		fsm:       fsm,
+		events:    events,
+	}
+}
+
+// SetDispatcher sets the CronDispatcher which runs triggered jobs, CronDispatcher records runs by JobService, so it
+// can not be passed to NewJobService.
+func (s *JobService) SetDispatcher(dispatcher *CronDispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// SetJob validates the job, assigns a JobID if missing and commits it through raft layer.
+func (s *JobService) SetJob(ctx context.Context, job *Job) (*Job, error) {
+	return s.applyJob(ctx, CommandSetJob, job)
+}
+
+// CreateJob works like SetJob, but it fails with ErrJobAlreadyExists if the JobID has been taken.
+func (s *JobService) CreateJob(ctx context.Context, job *Job) (*Job, error) {
+	return s.applyJob(ctx, CommandCreateJob, job)
+}
+
+// UpdateJob works like SetJob, but it fails with ErrJobNotFound if the job does not exist.
+func (s *JobService) UpdateJob(ctx context.Context, job *Job) (*Job, error) {
+	return s.applyJob(ctx, CommandUpdateJob, job)
+}
+
+func (s *JobService) applyJob(ctx context.Context, cmdType CommandType, job *Job) (*Job, error) {
+	ctx = logs.CtxAddKVs(ctx, constant.LogJobKey, job.JobKey)
+
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+
+	if job.JobID == "" {
+		job.JobID = NewJobID()
+	}
+	job.UpdateTime = time.Now()
+	job.resolveSchedule(job.UpdateTime)
+
+	resp, err := s.raftLayer.Apply(ctx, &Command{Type: cmdType, Job: job})
+	if err != nil {
+		logs.CtxError(ctx, "applyJob failed: type=%d, jobID=%s, err=%v", cmdType, job.JobID, err)
+		return nil, err
+	}
+
+	logs.CtxInfo(ctx, "applyJob successfully: type=%d, jobID=%s", cmdType, job.JobID)
+	return resp.(*Job), nil
+}
+
+// ListJobs reads a page of jobs selected by filter in the specific order. The page token is the position of the
+// last job in previous page, it can only be used with the same order.
+func (s *JobService) ListJobs(ctx context.Context, filter *JobFilter, orderBy JobOrderBy, descending bool, pageSize int,
+	pageToken string) ([]*Job, string, error) {
+	var cursor *jobCursor
+	if pageToken != "" {
+		var err error
+		if cursor, err = decodeJobCursor(pageToken); err != nil {
+			return nil, "", err
+		}
+		if cursor.OrderBy != orderBy || cursor.Descending != descending {
+			return nil, "", ErrInvalidPageToken
+		}
+	}
+
+	var jobs []*Job
+	for _, job := range s.fsm.ListJobs() {
+		if filter.Match(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	keys := sortJobs(jobs, orderBy, descending, time.Now())
+
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(keys), func(i int) bool {
+			if descending {
+				return keys[i].less(cursor.Last)
+			}
+			return cursor.Last.less(keys[i])
+		})
+	}
+
+	end := start + normalizePageSize(pageSize)
+	if end >= len(jobs) {
+		return jobs[start:], "", nil
+	}
+
+	next := &jobCursor{OrderBy: orderBy, Descending: descending, Last: keys[end-1]}
+	return jobs[start:end], next.encode(), nil
+}
+
+// GetJob reads a job from the replicated job table.
+func (s *JobService) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	return s.fsm.GetJob(jobID)
+}
+
+// DeleteJob removes a job through raft layer.
+func (s *JobService) DeleteJob(ctx context.Context, jobID string) (*Job, error) {
+	resp, err := s.raftLayer.Apply(ctx, &Command{Type: CommandDeleteJob, JobID: jobID})
+	if err != nil {
+		logs.CtxError(ctx, "DeleteJob failed: jobID=%s, err=%v", jobID, err)
+		return nil, err
+	}
+
+	job := resp.(*Job)
+	logs.CtxInfo(logs.CtxAddKVs(ctx, constant.LogJobKey, job.JobKey), "DeleteJob successfully: jobID=%s", jobID)
+	return job, nil
+}
+
+// PauseJob pauses a job through raft layer, CronDispatcher stops firing it and abandons its pending retries.
+func (s *JobService) PauseJob(ctx context.Context, jobID string) (*Job, error) {
+	return s.setJobPaused(ctx, CommandPauseJob, jobID)
+}
+
+// ResumeJob resumes a paused job through raft layer, fires missed during the pause are not caught up.
+func (s *JobService) ResumeJob(ctx context.Context, jobID string) (*Job, error) {
+	return s.setJobPaused(ctx, CommandResumeJob, jobID)
+}
+
+func (s *JobService) setJobPaused(ctx context.Context, cmdType CommandType, jobID string) (*Job, error) {
+	resp, err := s.raftLayer.Apply(ctx, &Command{Type: cmdType, JobID: jobID, Time: time.Now()})
+	if err != nil {
+		logs.CtxError(ctx, "setJobPaused failed: type=%d, jobID=%s, err=%v", cmdType, jobID, err)
+		return nil, err
+	}
+
+	job := resp.(*Job)
+	logs.CtxInfo(logs.CtxAddKVs(ctx, constant.LogJobKey, job.JobKey), "setJobPaused successfully: type=%d, jobID=%s",
+		cmdType, jobID)
+	return job, nil
+}
+
+// TriggerJob runs a job on demand by CronDispatcher, so it only works on raft leader. Paused jobs can be triggered.
+func (s *JobService) TriggerJob(ctx context.Context, jobID string) (*JobRun, error) {
+	job, err := s.fsm.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.dispatcher.TriggerJob(logs.CtxAddKVs(ctx, constant.LogJobKey, job.JobKey), job)
+}
+
+// DeleteJobs removes all jobs selected by filter through raft layer in a single command.
+func (s *JobService) DeleteJobs(ctx context.Context, filter *JobFilter) ([]*Job, error) {
+	var jobIDs []string
+	for _, job := range s.fsm.ListJobs() {
+		if filter.Match(job) {
+			jobIDs = append(jobIDs, job.JobID)
+		}
+	}
+
+	if len(jobIDs) == 0 {
+		return nil, nil
+	}
+
+	resp, err := s.raftLayer.Apply(ctx, &Command{Type: CommandDeleteJobs, JobIDs: jobIDs})
+	if err != nil {
+		logs.CtxError(ctx, "DeleteJobs failed: namespace=%s, jobs=%d, err=%v", filter.Namespace, len(jobIDs), err)
+		return nil, err
+	}
+
+	jobs := resp.([]*Job)
+	logs.CtxInfo(ctx, "DeleteJobs successfully: namespace=%s, jobs=%d", filter.Namespace, len(jobs))
+	return jobs, nil
+}
+
+// CompleteJob implements JobCompleter interface, it completes the job through raft layer if its schedule has been
+// exhausted.
+func (s *JobService) CompleteJob(ctx context.Context, jobID string) error {
+	resp, err := s.raftLayer.Apply(ctx, &Command{Type: CommandCompleteJob, JobID: jobID, Time: time.Now()})
+	if err != nil {
+		logs.CtxError(ctx, "CompleteJob failed: jobID=%s, err=%v", jobID, err)
+		return err
+	}
+
+	if job := resp.(*Job); !job.CompleteTime.IsZero() {
+		logs.CtxInfo(ctx, "CompleteJob successfully: jobID=%s", jobID)
+	}
+	return nil
+}
+
+// RecordJobRun implements JobRunRecorder interface, it commits the run through raft layer.
+func (s *JobService) RecordJobRun(ctx context.Context, run *JobRun) error {
+	if _, err := s.raftLayer.Apply(ctx, &Command{Type: CommandSetJobRun, Run: run}); err != nil {
+		logs.CtxError(ctx, "RecordJobRun failed: jobID=%s, runID=%s, err=%v", run.JobID, run.RunID, err)
+		return err
+	}
+
+	return nil
+}
+
+// GetJobRun reads a run from the replicated run history.
+func (s *JobService) GetJobRun(ctx context.Context, jobID, runID string) (*JobRun, error) {
+	return s.fsm.GetJobRun(jobID, runID)
+}
+
+// ListJobRuns reads a page of run history, the latest run comes first. The page token is the RunID of the last
+// run in previous page, so new runs will not shift pages.
+func (s *JobService) ListJobRuns(ctx context.Context, jobID string, pageSize int, pageToken string) ([]*JobRun, string, error) {
+	runs, err := s.fsm.ListJobRuns(jobID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	start := 0
+	if pageToken != "" {
+		start = -1
+		for i, run := range runs {
+			if run.RunID == pageToken {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return nil, "", ErrInvalidPageToken
+		}
+	}
+
+	end := start + normalizePageSize(pageSize)
+	if end >= len(runs) {
+		return runs[start:], "", nil
+	}
+
+	return runs[start:end], runs[end-1].RunID, nil
+}
+
+// PreviewSchedule parses the schedule as CronDispatcher does and returns its next fire times after start, a
+// malformed schedule fails with *ScheduleError.
+func (s *JobService) PreviewSchedule(ctx context.Context, expression, timeZone string, start time.Time, count int) ([]time.Time, error) {
+	schedule, err := parseSchedule(expression, timeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	if count <= 0 {
+		count = defaultPreviewCount
+	}
+	if count > maxPreviewCount {
+		count = maxPreviewCount
+	}
+
+	return previewSchedule(schedule, start, count), nil
+}
+
+// WatchJobs watches job events after the revision, jobs are selected by filter and also by jobID if it is not empty.
+// Watches are served by the local JobFSM, so followers serve them as well.
+func (s *JobService) WatchJobs(ctx context.Context, revision uint64, filter *JobFilter, jobID string) (*Watcher, error) {
+	watcher, err := s.events.Watch(revision, func(event *JobEvent) bool {
+		return !event.Type.IsRunEvent() && (jobID == "" || event.Job.JobID == jobID) && filter.Match(event.Job)
+	})
+	if err != nil {
+		logs.CtxWarn(ctx, "WatchJobs failed: revision=%d, err=%v", revision, err)
+		return nil, err
+	}
+
+	return watcher, nil
+}
+
+// WatchRuns watches run events after the revision, runs are selected by namespace and jobID of their jobs if they are
+// not empty.
+func (s *JobService) WatchRuns(ctx context.Context, revision uint64, namespace, jobID string) (*Watcher, error) {
+	watcher, err := s.events.Watch(revision, func(event *JobEvent) bool {
+		return event.Type.IsRunEvent() && (jobID == "" || event.Job.JobID == jobID) &&
+			(namespace == "" || event.Job.Namespace == namespace)
+	})
+	if err != nil {
+		logs.CtxWarn(ctx, "WatchRuns failed: revision=%d, err=%v", revision, err)
+		return nil, err
+	}
+
+	return watcher, nil
+}
+
+// normalizePageSize applies default and maximum page size.
+func normalizePageSize(pageSize int) int {
+	if pageSize <= 0 {
+		return defaultPageSize
+	}
+
+	if pageSize > maxPageSize {
+		return maxPageSize
+	}
+
+	return pageSize
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newTestJob(jobID, namespace, jobKey, displayName string) *Job {
+	return &Job{
+		JobID:          jobID,
+		Namespace:      namespace,
+		JobKey:         jobKey,
+		JobDisplayName: displayName,
+		CronExpression: "0 0 0 * * *",
+		ExecutorType:   ExecutorTypeShell,
+		Shell:          &ShellConfig{Command: "true"},
+	}
+}
+
+// newTestJobService creates JobService reading from a JobFSM holding jobs.
+func newTestJobService(t *testing.T, jobs ...*Job) *JobService {
+	t.Helper()
+
+	fsm := NewJobFSM(DefaultConfig().RunHistoryMax, nil)
+	for i, job := range jobs {
+		applyTestCommand(t, fsm, uint64(i+1), &Command{Type: CommandCreateJob, Job: job})
+	}
+	return &JobService{fsm: fsm}
+}
+
+func listJobIDs(t *testing.T, s *JobService, filter *JobFilter, orderBy JobOrderBy, descending bool, pageSize int) []string {
+	t.Helper()
+
+	var ids []string
+	token := ""
+	for page := 0; ; page++ {
+		jobs, next, err := s.ListJobs(context.Background(), filter, orderBy, descending, pageSize, token)
+		if err != nil {
+			t.Fatalf("ListJobs failed: page=%d, err=%v", page, err)
+		}
+		if len(jobs) == 0 && next == "" && page > 0 {
+			t.Fatalf("ListJobs returned an empty last page: page=%d", page)
+		}
+		for _, job := range jobs {
+			ids = append(ids, job.JobID)
+		}
+
+		if next == "" {
+			return ids
+		}
+		token = next
+	}
+}
+
+func TestListJobsPages(t *testing.T) {
+	s := newTestJobService(t,
+		newTestJob("1", "b", "alpha", "Echo"),
+		newTestJob("2", "a", "delta", "Bravo"),
+		newTestJob("3", "a", "charlie", "Alpha"),
+		newTestJob("4", "b", "bravo", "Delta"),
+		newTestJob("5", "a", "echo", "Charlie"),
+	)
+
+	tests := []struct {
+		name       string
+		filter     *JobFilter
+		orderBy    JobOrderBy
+		descending bool
+		pageSize   int
+		want       []string
+	}{
+		{name: "by key", orderBy: JobOrderByKey, pageSize: 2, want: []string{"3", "2", "5", "1", "4"}},
+		{name: "by key descending", orderBy: JobOrderByKey, descending: true, pageSize: 2,
+			want: []string{"4", "1", "5", "2", "3"}},
+		{name: "by name", orderBy: JobOrderByName, pageSize: 3, want: []string{"3", "2", "5", "4", "1"}},
+		{name: "single page", orderBy: JobOrderByKey, pageSize: 5, want: []string{"3", "2", "5", "1", "4"}},
+		{name: "page per job", orderBy: JobOrderByKey, pageSize: 1, want: []string{"3", "2", "5", "1", "4"}},
+		{name: "filtered exact pages", filter: &JobFilter{Namespace: "a"}, orderBy: JobOrderByKey, pageSize: 3,
+			want: []string{"3", "2", "5"}},
+		{name: "filtered by prefix", filter: &JobFilter{KeyPrefix: "d"}, orderBy: JobOrderByKey, pageSize: 1,
+			want: []string{"2"}},
+		{name: "nothing matched", filter: &JobFilter{Namespace: "c"}, orderBy: JobOrderByKey, pageSize: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			if filter == nil {
+				filter = &JobFilter{}
+			}
+
+			if got := listJobIDs(t, s, filter, tt.orderBy, tt.descending, tt.pageSize); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListJobs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListJobsPageKeepsPositionAfterDelete(t *testing.T) {
+	s := newTestJobService(t,
+		newTestJob("1", "a", "a", ""),
+		newTestJob("2", "a", "b", ""),
+		newTestJob("3", "a", "c", ""),
+		newTestJob("4", "a", "d", ""),
+	)
+
+	jobs, token, err := s.ListJobs(context.Background(), &JobFilter{}, JobOrderByKey, false, 2, "")
+	if err != nil || len(jobs) != 2 || token == "" {
+		t.Fatalf("ListJobs() = %d jobs, token %q, err %v", len(jobs), token, err)
+	}
+
+	// The last job of the first page is deleted before the next page is read.
+	applyTestCommand(t, s.fsm, 5, &Command{Type: CommandDeleteJob, JobID: "2"})
+
+	jobs, token, err = s.ListJobs(context.Background(), &JobFilter{}, JobOrderByKey, false, 2, token)
+	if err != nil {
+		t.Fatalf("ListJobs failed: err=%v", err)
+	}
+	if len(jobs) != 2 || jobs[0].JobID != "3" || jobs[1].JobID != "4" || token != "" {
+		t.Errorf("ListJobs() = %v, token %q, want [3 4] without token", jobs, token)
+	}
+}
+
+func TestListJobsInvalidPageToken(t *testing.T) {
+	s := newTestJobService(t, newTestJob("1", "a", "a", ""), newTestJob("2", "a", "b", ""), newTestJob("3", "a", "c", ""))
+
+	_, token, err := s.ListJobs(context.Background(), &JobFilter{}, JobOrderByKey, false, 1, "")
+	if err != nil || token == "" {
+		t.Fatalf("ListJobs() token %q, err %v", token, err)
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		orderBy    JobOrderBy
+		descending bool
+	}{
+		{name: "not base64", token: "!!!", orderBy: JobOrderByKey},
+		{name: "standard base64", token: base64.StdEncoding.EncodeToString([]byte(`{"o":0,"l":{"i":"1"}}`)) + "+/",
+			orderBy: JobOrderByKey},
+		{name: "not json", token: base64.RawURLEncoding.EncodeToString([]byte("not json")), orderBy: JobOrderByKey},
+		{name: "wrong json type", token: base64.RawURLEncoding.EncodeToString([]byte(`{"o":"key"}`)),
+			orderBy: JobOrderByKey},
+		{name: "truncated", token: token[:len(token)-2], orderBy: JobOrderByKey},
+		{name: "other order", token: token, orderBy: JobOrderByName},
+		{name: "other direction", token: token, orderBy: JobOrderByKey, descending: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := s.ListJobs(context.Background(), &JobFilter{}, tt.orderBy, tt.descending, 1, tt.token)
+			if !errors.Is(err, ErrInvalidPageToken) {
+				t.Errorf("ListJobs() err = %v, want ErrInvalidPageToken", err)
+			}
+		})
+	}
+}
+
+func TestJobFilterMatch(t *testing.T) {
+	paused, active := true, false
+	job := newTestJob("1", "prod", "billing-report", "")
+	job.Labels = map[string]string{"team": "billing"}
+
+	tests := []struct {
+		name     string
+		filter   *JobFilter
+		selector string
+		want     bool
+	}{
+		{name: "empty filter", filter: &JobFilter{}, want: true},
+		{name: "namespace", filter: &JobFilter{Namespace: "prod"}, want: true},
+		{name: "other namespace", filter: &JobFilter{Namespace: "dev"}, want: false},
+		{name: "key prefix", filter: &JobFilter{KeyPrefix: "billing-"}, want: true},
+		{name: "other key prefix", filter: &JobFilter{KeyPrefix: "report"}, want: false},
+		{name: "executor type", filter: &JobFilter{ExecutorType: ExecutorTypeShell}, want: true},
+		{name: "other executor type", filter: &JobFilter{ExecutorType: ExecutorTypeHTTP}, want: false},
+		{name: "active", filter: &JobFilter{Paused: &active}, want: true},
+		{name: "paused", filter: &JobFilter{Paused: &paused}, want: false},
+		{name: "selector", filter: &JobFilter{}, selector: "team=billing", want: true},
+		{name: "other selector", filter: &JobFilter{}, selector: "team=infra", want: false},
+		{name: "all fields", filter: &JobFilter{Namespace: "prod", KeyPrefix: "billing", ExecutorType: ExecutorTypeShell,
+			Paused: &active}, selector: "team", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.selector != "" {
+				selector, err := ParseLabelSelector(tt.selector)
+				if err != nil {
+					t.Fatalf("ParseLabelSelector failed: err=%v", err)
+				}
+				tt.filter.Selector = selector
+			}
+
+			if got := tt.filter.Match(job); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
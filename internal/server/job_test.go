@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KevinWu0904/crond/proto/types"
+)
+
+func TestJobExhaustedIgnoresLocalZone(t *testing.T) {
+	job := &Job{
+		ScheduleKind:   ScheduleKindCron,
+		CronExpression: "0 0 12 * * *",
+		EndTime:        mustParseTime(t, "2026-06-15T14:00:00Z"),
+	}
+	after := mustParseTime(t, "2026-06-15T13:00:00Z")
+
+	local := time.Local
+	defer func() { time.Local = local }()
+
+	for _, zone := range []string{"UTC", dstTimeZone, "Asia/Shanghai"} {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			t.Fatalf("LoadLocation failed: zone=%s, err=%v", zone, err)
+		}
+		time.Local = loc
+
+		if !job.exhausted(after) {
+			t.Errorf("exhausted() = false with local zone %s, want true", zone)
+		}
+	}
+}
+
+func TestNewJobFromProtoRejectsOverflowingSeconds(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     *types.Job
+		wantErr bool
+	}{
+		{
+			name:    "interval at limit",
+			job:     &types.Job{ScheduleKind: types.ScheduleKind_SCHEDULE_KIND_INTERVAL, IntervalSeconds: maxDurationSeconds},
+			wantErr: false,
+		},
+		{
+			name:    "interval overflows",
+			job:     &types.Job{ScheduleKind: types.ScheduleKind_SCHEDULE_KIND_INTERVAL, IntervalSeconds: 18446744074},
+			wantErr: true,
+		},
+		{
+			name:    "delay overflows",
+			job:     &types.Job{ScheduleKind: types.ScheduleKind_SCHEDULE_KIND_DELAY, DelaySeconds: maxDurationSeconds + 1},
+			wantErr: true,
+		},
+		{
+			name:    "timeout overflows",
+			job:     &types.Job{CronExpression: "0 * * * * *", TimeoutSeconds: maxDurationSeconds + 1},
+			wantErr: true,
+		},
+		{
+			name:    "negative timeout overflows",
+			job:     &types.Job{CronExpression: "0 * * * * *", TimeoutSeconds: -maxDurationSeconds - 1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.job.JobKey = "job"
+			tt.job.ExecutorType = types.ExecutorType_EXECUTOR_TYPE_SHELL
+			tt.job.ExecutorConfig = &types.Job_Shell{Shell: &types.ShellExecutorConfig{Command: "true"}}
+
+			err := NewJobFromProto(tt.job).Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidJob) {
+				t.Errorf("Validate() err = %v, want ErrInvalidJob", err)
+			}
+		})
+	}
+}
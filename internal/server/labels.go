@@ -0,0 +1,205 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultNamespace is the namespace of jobs created without namespace.
+const DefaultNamespace = "default"
+
+const maxLabelLength = 63
+
+// ErrInvalidLabelSelector throws when the label selector can not be parsed.
+var ErrInvalidLabelSelector = errors.New("invalid label selector")
+
+var (
+	namespacePattern  = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_./]*[A-Za-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?)?$`)
+	setRequirement    = regexp.MustCompile(`^(\S+)\s+(in|notin)\s+\((.*)\)$`)
+)
+
+// validateNamespace checks namespace like a DNS label, e.g. "team-a".
+func validateNamespace(namespace string) error {
+	if len(namespace) > maxLabelLength || !namespacePattern.MatchString(namespace) {
+		return fmt.Errorf("namespace %q must be a lower case DNS label of at most %d characters", namespace,
+			maxLabelLength)
+	}
+
+	return nil
+}
+
+func validateLabelKey(key string) error {
+	if len(key) > maxLabelLength || !labelKeyPattern.MatchString(key) {
+		return fmt.Errorf("label key %q is malformed", key)
+	}
+
+	return nil
+}
+
+func validateLabelValue(value string) error {
+	if len(value) > maxLabelLength || !labelValuePattern.MatchString(value) {
+		return fmt.Errorf("label value %q is malformed", value)
+	}
+
+	return nil
+}
+
+// validateLabels checks label keys and values, both are at most 63 alphanumeric, '-', '_' or '.' characters
+// starting and ending with alphanumerics, keys may also contain '/'.
+func validateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if err := validateLabelKey(key); err != nil {
+			return err
+		}
+		if err := validateLabelValue(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type labelOperator int8
+
+const (
+	labelExists labelOperator = iota
+	labelNotExists
+	labelEquals
+	labelNotEquals
+	labelIn
+	labelNotIn
+)
+
+type labelRequirement struct {
+	key      string
+	operator labelOperator
+	values   []string
+}
+
+func (r *labelRequirement) matches(labels map[string]string) bool {
+	value, ok := labels[r.key]
+
+	switch r.operator {
+	case labelExists:
+		return ok
+	case labelNotExists:
+		return !ok
+	case labelEquals:
+		return ok && value == r.values[0]
+	case labelNotEquals:
+		return !ok || value != r.values[0]
+	case labelIn:
+		return ok && containsString(r.values, value)
+	case labelNotIn:
+		return !ok || !containsString(r.values, value)
+	default:
+		return false
+	}
+}
+
+// LabelSelector selects jobs by labels, all requirements must be satisfied. An empty LabelSelector selects all jobs.
+type LabelSelector []labelRequirement
+
+// ParseLabelSelector parses comma separated requirements in the syntax of Kubernetes label selectors:
+// "key", "!key", "key=value", "key==value", "key!=value", "key in (v1,v2)" and "key notin (v1,v2)".
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	var s LabelSelector
+	for _, term := range splitSelector(selector) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		r, err := parseLabelRequirement(term)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidLabelSelector, err)
+		}
+		s = append(s, r)
+	}
+
+	return s, nil
+}
+
+// Matches reports whether the labels satisfy all requirements.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for i := range s {
+		if !s[i].matches(labels) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseLabelRequirement(term string) (labelRequirement, error) {
+	var r labelRequirement
+
+	if m := setRequirement.FindStringSubmatch(term); m != nil {
+		r.key, r.operator = m[1], labelIn
+		if m[2] == "notin" {
+			r.operator = labelNotIn
+		}
+		for _, value := range strings.Split(m[3], ",") {
+			r.values = append(r.values, strings.TrimSpace(value))
+		}
+	} else if strings.HasPrefix(term, "!") {
+		r.key, r.operator = strings.TrimSpace(term[1:]), labelNotExists
+	} else if i := strings.Index(term, "!="); i >= 0 {
+		r.key, r.operator, r.values = term[:i], labelNotEquals, []string{term[i+2:]}
+	} else if i := strings.Index(term, "=="); i >= 0 {
+		r.key, r.operator, r.values = term[:i], labelEquals, []string{term[i+2:]}
+	} else if i := strings.Index(term, "="); i >= 0 {
+		r.key, r.operator, r.values = term[:i], labelEquals, []string{term[i+1:]}
+	} else {
+		r.key, r.operator = term, labelExists
+	}
+
+	r.key = strings.TrimSpace(r.key)
+	if err := validateLabelKey(r.key); err != nil {
+		return r, err
+	}
+	for i := range r.values {
+		r.values[i] = strings.TrimSpace(r.values[i])
+		if err := validateLabelValue(r.values[i]); err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
+
+// splitSelector splits the selector by commas outside parentheses.
+func splitSelector(selector string) []string {
+	var terms []string
+
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(terms, selector[start:])
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
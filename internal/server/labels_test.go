@@ -0,0 +1,100 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	prod := map[string]string{"env": "prod", "tier": "web"}
+	dev := map[string]string{"env": "dev"}
+	none := map[string]string{}
+
+	tests := []struct {
+		name     string
+		selector string
+		labels   []map[string]string
+		want     []bool
+	}{
+		{name: "empty", selector: "", labels: []map[string]string{prod, dev, none}, want: []bool{true, true, true}},
+		{name: "blank terms", selector: " , ,", labels: []map[string]string{prod, none}, want: []bool{true, true}},
+		{name: "exists", selector: "tier", labels: []map[string]string{prod, dev, none}, want: []bool{true, false, false}},
+		{name: "not exists", selector: "!tier", labels: []map[string]string{prod, dev, none}, want: []bool{false, true, true}},
+		{name: "equals", selector: "env=prod", labels: []map[string]string{prod, dev, none}, want: []bool{true, false, false}},
+		{name: "double equals", selector: "env==dev", labels: []map[string]string{prod, dev, none}, want: []bool{false, true, false}},
+		{name: "not equals", selector: "env!=prod", labels: []map[string]string{prod, dev, none}, want: []bool{false, true, true}},
+		{
+			name:     "equals empty value",
+			selector: "env=",
+			labels:   []map[string]string{prod, {"env": ""}, none},
+			want:     []bool{false, true, false},
+		},
+		{
+			name:     "in",
+			selector: "env in (prod, staging)",
+			labels:   []map[string]string{prod, dev, none},
+			want:     []bool{true, false, false},
+		},
+		{
+			name:     "notin",
+			selector: "env notin (prod,staging)",
+			labels:   []map[string]string{prod, dev, none},
+			want:     []bool{false, true, true},
+		},
+		{
+			name:     "all requirements",
+			selector: " env = prod , tier in (web,api), !canary",
+			labels:   []map[string]string{prod, {"env": "prod", "tier": "db"}, {"env": "prod", "tier": "web", "canary": ""}},
+			want:     []bool{true, false, false},
+		},
+		{
+			name:     "prefixed key",
+			selector: "example.com/team=a",
+			labels:   []map[string]string{{"example.com/team": "a"}, {"team": "a"}},
+			want:     []bool{true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseLabelSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("ParseLabelSelector failed: err=%v", err)
+			}
+
+			for i, labels := range tt.labels {
+				if got := s.Matches(labels); got != tt.want[i] {
+					t.Errorf("Matches(%v) = %v, want %v", labels, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseLabelSelectorInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+	}{
+		{name: "missing key", selector: "=prod"},
+		{name: "missing not exists key", selector: "!"},
+		{name: "key starts with dash", selector: "-env"},
+		{name: "key with space", selector: "my env=prod"},
+		{name: "value with space", selector: "env=pro d"},
+		{name: "value ends with dot", selector: "env=prod."},
+		{name: "set value with space", selector: "env in (prod, sta ging)"},
+		{name: "unclosed set", selector: "env in (prod"},
+		{name: "key too long", selector: strings.Repeat("k", maxLabelLength+1)},
+		{name: "value too long", selector: "env=" + strings.Repeat("v", maxLabelLength+1)},
+		{name: "one invalid term", selector: "env=prod,tier=w@b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseLabelSelector(tt.selector); !errors.Is(err, ErrInvalidLabelSelector) {
+				t.Errorf("ParseLabelSelector() err = %v, want %v", err, ErrInvalidLabelSelector)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"os"
+	"testing"
+
+	"github.com/KevinWu0904/crond/pkg/logs"
+)
+
+func TestMain(m *testing.M) {
+	c := logs.DefaultConfig()
+	c.LogLevel = "fatal"
+	if err := logs.InitLogger(c); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	logs.Flush()
+	os.Exit(code)
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	gometrics "github.com/armon/go-metrics"
+	gometricsprometheus "github.com/armon/go-metrics/prometheus"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// metricsNamespace prefixes all crond metrics, raft metrics bridged from go-metrics share it as well.
+const metricsNamespace = "crond"
+
+var (
+	jobRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "job_runs_total",
+		Help:      "Number of finished job runs, including skipped runs.",
+	}, []string{"executor", "status"})
+
+	jobRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "job_run_duration_seconds",
+		Help:      "Duration of executed job runs.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 10),
+	}, []string{"executor", "status"})
+
+	jobScheduleLag = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "job_schedule_lag_seconds",
+		Help:      "Delay between the scheduled time and the actual start time of scheduled runs.",
+		Buckets:   []float64{0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+	}, []string{"executor"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP API requests by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+
+	grpcRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "Latency of gRPC API requests by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+)
+
+func init() {
+	prometheus.MustRegister(jobRunsTotal, jobRunDuration, jobScheduleLag, httpRequestDuration, grpcRequestDuration)
+}
+
+// observeRunStarted records the schedule lag of a run fired by the job schedule, manual runs and retries start late
+// on purpose, so they are not observed.
+func observeRunStarted(job *Job, run *JobRun) {
+	if run.Trigger == JobRunTriggerManual || run.Attempt > 1 {
+		return
+	}
+
+	jobScheduleLag.WithLabelValues(job.ExecutorType.String()).Observe(run.StartTime.Sub(run.ScheduledTime).Seconds())
+}
+
+// observeRunFinished records a finished run, the duration is only observed for runs executed by the current node.
+func observeRunFinished(job *Job, run *JobRun, executed bool) {
+	executor, status := job.ExecutorType.String(), run.Status.String()
+
+	jobRunsTotal.WithLabelValues(executor, status).Inc()
+	if executed {
+		jobRunDuration.WithLabelValues(executor, status).Observe(run.EndTime.Sub(run.StartTime).Seconds())
+	}
+}
+
+// StateCollector implements prometheus.Collector, it reports the state of JobFSM and CronDispatcher on scrape.
+type StateCollector struct {
+	fsm        *JobFSM
+	dispatcher *CronDispatcher
+
+	jobs              *prometheus.Desc
+	dispatcherStarted *prometheus.Desc
+	dispatcherEntries *prometheus.Desc
+	dispatcherRunning *prometheus.Desc
+}
+
+// NewStateCollector creates StateCollector.
+func NewStateCollector(fsm *JobFSM, dispatcher *CronDispatcher) *StateCollector {
+	return &StateCollector{
+		fsm:        fsm,
+		dispatcher: dispatcher,
+		jobs: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "jobs"),
+			"Number of jobs in the replicated job table by state.", []string{"state"}, nil),
+		dispatcherStarted: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "dispatcher", "started"),
+			"Whether CronDispatcher is running on the current node.", nil, nil),
+		dispatcherEntries: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "dispatcher", "entries"),
+			"Number of jobs scheduled by CronDispatcher.", nil, nil),
+		dispatcherRunning: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "dispatcher", "running_runs"),
+			"Number of in-flight runs of CronDispatcher.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector interface.
+func (c *StateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.jobs
+	ch <- c.dispatcherStarted
+	ch <- c.dispatcherEntries
+	ch <- c.dispatcherRunning
+}
+
+// Collect implements prometheus.Collector interface.
+func (c *StateCollector) Collect(ch chan<- prometheus.Metric) {
+	active, paused, completed := c.fsm.CountJobs()
+	ch <- prometheus.MustNewConstMetric(c.jobs, prometheus.GaugeValue, float64(active), "active")
+	ch <- prometheus.MustNewConstMetric(c.jobs, prometheus.GaugeValue, float64(paused), "paused")
+	ch <- prometheus.MustNewConstMetric(c.jobs, prometheus.GaugeValue, float64(completed), "completed")
+
+	status := c.dispatcher.Status()
+	started := 0.0
+	if status.Started {
+		started = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.dispatcherStarted, prometheus.GaugeValue, started)
+	ch <- prometheus.MustNewConstMetric(c.dispatcherEntries, prometheus.GaugeValue, float64(status.Jobs))
+	ch <- prometheus.MustNewConstMetric(c.dispatcherRunning, prometheus.GaugeValue, float64(status.RunningRuns))
+}
+
+var (
+	raftMetricsOnce sync.Once
+	raftMetricsErr  error
+)
+
+// InitRaftMetrics bridges metrics emitted by hashicorp/raft through go-metrics into prometheus, it must be called
+// before raft layer is created. go-metrics sink is process-wide, so the bridge is only set up by the first call.
+func InitRaftMetrics() error {
+	raftMetricsOnce.Do(func() {
+		var sink *gometricsprometheus.PrometheusSink
+		if sink, raftMetricsErr = gometricsprometheus.NewPrometheusSink(); raftMetricsErr != nil {
+			return
+		}
+
+		conf := gometrics.DefaultConfig(metricsNamespace)
+		conf.EnableHostname = false
+		conf.EnableRuntimeMetrics = false // Runtime metrics are reported by prometheus Go collector already.
+
+		_, raftMetricsErr = gometrics.NewGlobal(conf, sink)
+	})
+
+	return raftMetricsErr
+}
+
+// HTTPMetrics is a gin middleware observing HTTP request latency by route, unmatched requests share one route label.
+func HTTPMetrics(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+		Observe(time.Since(start).Seconds())
+}
+
+// UnaryServerMetrics is a gRPC unary interceptor observing request latency by method.
+func UnaryServerMetrics(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	grpcRequestDuration.WithLabelValues(info.FullMethod, status.Code(err).String()).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+// StreamServerMetrics is a gRPC stream interceptor observing request latency by method.
+func StreamServerMetrics(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+
+	grpcRequestDuration.WithLabelValues(info.FullMethod, status.Code(err).String()).Observe(time.Since(start).Seconds())
+	return err
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/KevinWu0904/crond/proto/types"
+	"github.com/robfig/cron/v3"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	defaultMisfireMaxFires = 10
+	// misfireMaxScan bounds the number of schedule iterations in a single window, it is only a safeguard since
+	// windows grow from misfireInitialWindow until they hold enough fires.
+	misfireMaxScan = 100000
+	// misfireInitialWindow is the first window scanned back from now.
+	misfireInitialWindow = time.Minute
+)
+
+// MisfireMode defines how fires missed during leader failover are handled.
+type MisfireMode int8
+
+const (
+	// MisfireModeSkip drops all missed fires.
+	MisfireModeSkip MisfireMode = iota
+	// MisfireModeFireOnce fires once for the latest missed fire.
+	MisfireModeFireOnce
+	// MisfireModeFireAll fires for each missed fire, at most MaxFires latest ones.
+	MisfireModeFireAll
+)
+
+// MisfirePolicy defines which missed fires CronDispatcher catches up when it starts on a new leader.
+// Fires older than StartingDeadline are dropped, zero StartingDeadline means unlimited. Zero MaxFires falls back to 10.
+type MisfirePolicy struct {
+	Mode             MisfireMode   `json:"mode"`
+	StartingDeadline time.Duration `json:"starting_deadline"`
+	MaxFires         int32         `json:"max_fires"`
+}
+
+// NewMisfirePolicyFromProto converts types.MisfirePolicy into MisfirePolicy.
+func NewMisfirePolicyFromProto(pb *types.MisfirePolicy) *MisfirePolicy {
+	if pb == nil {
+		return nil
+	}
+
+	return &MisfirePolicy{
+		Mode:             MisfireMode(pb.GetMode()),
+		StartingDeadline: pb.GetStartingDeadline().AsDuration(),
+		MaxFires:         pb.GetMaxFires(),
+	}
+}
+
+// ToProto converts MisfirePolicy into types.MisfirePolicy.
+func (p *MisfirePolicy) ToProto() *types.MisfirePolicy {
+	return &types.MisfirePolicy{
+		Mode:             types.MisfireMode(p.Mode),
+		StartingDeadline: durationpb.New(p.StartingDeadline),
+		MaxFires:         p.MaxFires,
+	}
+}
+
+// Clone returns a copy of MisfirePolicy.
+func (p *MisfirePolicy) Clone() *MisfirePolicy {
+	clone := *p
+	return &clone
+}
+
+// Validate checks whether MisfirePolicy is well-formed.
+func (p *MisfirePolicy) Validate() error {
+	switch {
+	case p.Mode < MisfireModeSkip || p.Mode > MisfireModeFireAll:
+		return errors.New("misfire mode is not supported")
+	case p.StartingDeadline < 0:
+		return errors.New("misfire starting_deadline must not be negative")
+	case p.MaxFires < 0:
+		return errors.New("misfire max_fires must not be negative")
+	}
+
+	return nil
+}
+
+// Misfires returns the fires of schedule in (last, now] which should be caught up, the earliest comes first.
+func (p *MisfirePolicy) Misfires(schedule cron.Schedule, last, now time.Time) []time.Time {
+	if p == nil || p.Mode == MisfireModeSkip || last.IsZero() {
+		return nil
+	}
+
+	limit := 1
+	if p.Mode == MisfireModeFireAll {
+		limit = int(p.MaxFires)
+		if limit == 0 {
+			limit = defaultMisfireMaxFires
+		}
+	}
+
+	// Schedule.Next is strictly after the given time, so fires right at the deadline are still included.
+	if deadline := now.Add(-p.StartingDeadline - time.Nanosecond); p.StartingDeadline > 0 && last.Before(deadline) {
+		last = deadline
+	}
+
+	// cron.Schedule can only look forward, so windows ending at now are scanned and doubled until they hold limit
+	// fires or reach last. The latest fires are found without scanning a long outage from its start. Doubling stops
+	// before time.Duration overflows.
+	for window := misfireInitialWindow; window < now.Sub(last) && window <= math.MaxInt64/2; window *= 2 {
+		if fires := scanFires(schedule, now.Add(-window), now, limit); len(fires) >= limit {
+			return fires
+		}
+	}
+
+	return scanFires(schedule, last, now, limit)
+}
+
+// scanFires returns at most limit latest fires of schedule in (from, to], the earliest comes first.
+func scanFires(schedule cron.Schedule, from, to time.Time, limit int) []time.Time {
+	var fires []time.Time
+	for i, t := 0, schedule.Next(from); i < misfireMaxScan && !t.IsZero() && !t.After(to); i, t = i+1, schedule.Next(t) {
+		fires = append(fires, t)
+		if len(fires) > limit {
+			fires = fires[1:]
+		}
+	}
+
+	return fires
+}
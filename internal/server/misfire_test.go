@@ -0,0 +1,164 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestMisfirePolicyMisfires(t *testing.T) {
+	now := mustParseTime(t, "2026-01-03T00:00:00Z")
+
+	tests := []struct {
+		name       string
+		policy     *MisfirePolicy
+		expression string
+		end        string
+		last       string
+		want       []string
+	}{
+		{
+			name:       "nil policy",
+			expression: "* * * * * *",
+			last:       "2026-01-02T23:00:00Z",
+		},
+		{
+			name:       "skip",
+			policy:     &MisfirePolicy{Mode: MisfireModeSkip},
+			expression: "* * * * * *",
+			last:       "2026-01-02T23:00:00Z",
+		},
+		{
+			name:       "never fired",
+			policy:     &MisfirePolicy{Mode: MisfireModeFireAll},
+			expression: "* * * * * *",
+			last:       "0001-01-01T00:00:00Z",
+		},
+		{
+			name:       "nothing missed",
+			policy:     &MisfirePolicy{Mode: MisfireModeFireAll},
+			expression: "0 0 * * * *",
+			last:       "2026-01-02T23:30:00Z",
+			want:       []string{"2026-01-03T00:00:00Z"},
+		},
+		{
+			name:       "fire once after long outage",
+			policy:     &MisfirePolicy{Mode: MisfireModeFireOnce},
+			expression: "* * * * * *",
+			last:       "2026-01-01T00:00:00Z",
+			want:       []string{"2026-01-03T00:00:00Z"},
+		},
+		{
+			name:       "fire all keeps latest fires",
+			policy:     &MisfirePolicy{Mode: MisfireModeFireAll, MaxFires: 3},
+			expression: "* * * * * *",
+			last:       "2026-01-01T00:00:00Z",
+			want:       []string{"2026-01-02T23:59:58Z", "2026-01-02T23:59:59Z", "2026-01-03T00:00:00Z"},
+		},
+		{
+			name:       "fire all defaults to 10 fires",
+			policy:     &MisfirePolicy{Mode: MisfireModeFireAll},
+			expression: "0 * * * * *",
+			last:       "2026-01-02T00:00:00Z",
+			want: []string{"2026-01-02T23:51:00Z", "2026-01-02T23:52:00Z", "2026-01-02T23:53:00Z",
+				"2026-01-02T23:54:00Z", "2026-01-02T23:55:00Z", "2026-01-02T23:56:00Z", "2026-01-02T23:57:00Z",
+				"2026-01-02T23:58:00Z", "2026-01-02T23:59:00Z", "2026-01-03T00:00:00Z"},
+		},
+		{
+			name:       "fewer fires than limit",
+			policy:     &MisfirePolicy{Mode: MisfireModeFireAll},
+			expression: "0 0 * * * *",
+			last:       "2026-01-02T21:30:00Z",
+			want:       []string{"2026-01-02T22:00:00Z", "2026-01-02T23:00:00Z", "2026-01-03T00:00:00Z"},
+		},
+		{
+			name:       "last fire excluded",
+			policy:     &MisfirePolicy{Mode: MisfireModeFireAll},
+			expression: "0 0 * * * *",
+			last:       "2026-01-02T23:00:00Z",
+			want:       []string{"2026-01-03T00:00:00Z"},
+		},
+		{
+			name:       "starting deadline includes fire at deadline",
+			policy:     &MisfirePolicy{Mode: MisfireModeFireAll, StartingDeadline: time.Minute * 2},
+			expression: "0 * * * * *",
+			last:       "2026-01-02T00:00:00Z",
+			want:       []string{"2026-01-02T23:58:00Z", "2026-01-02T23:59:00Z", "2026-01-03T00:00:00Z"},
+		},
+		{
+			name:       "starting deadline drops all fires",
+			policy:     &MisfirePolicy{Mode: MisfireModeFireOnce, StartingDeadline: time.Minute * 30},
+			expression: "0 0 12 * * *",
+			last:       "2026-01-01T00:00:00Z",
+		},
+		{
+			name:       "sparse schedule spans many windows",
+			policy:     &MisfirePolicy{Mode: MisfireModeFireAll, MaxFires: 3},
+			expression: "0 0 0 1 1 *",
+			last:       "2016-01-03T00:00:00Z",
+			want:       []string{"2024-01-01T00:00:00Z", "2025-01-01T00:00:00Z", "2026-01-01T00:00:00Z"},
+		},
+		{
+			name:       "outage beyond Duration range",
+			policy:     &MisfirePolicy{Mode: MisfireModeFireOnce},
+			expression: "0 0 0 1 1 *",
+			last:       "1500-01-03T00:00:00Z",
+			want:       []string{"2026-01-01T00:00:00Z"},
+		},
+		{
+			name:       "ended schedule",
+			policy:     &MisfirePolicy{Mode: MisfireModeFireAll},
+			expression: "0 0 * * * *",
+			end:        "2026-01-02T22:00:00Z",
+			last:       "2026-01-02T20:30:00Z",
+			want:       []string{"2026-01-02T21:00:00Z", "2026-01-02T22:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schedule cron.Schedule
+			schedule, err := parseSchedule(tt.expression, "")
+			if err != nil {
+				t.Fatalf("parseSchedule failed: err=%v", err)
+			}
+			if tt.end != "" {
+				schedule = &endingSchedule{schedule: schedule, end: mustParseTime(t, tt.end)}
+			}
+
+			var want []time.Time
+			for _, fire := range tt.want {
+				want = append(want, mustParseTime(t, fire))
+			}
+
+			if got := tt.policy.Misfires(schedule, mustParseTime(t, tt.last), now); !reflect.DeepEqual(got, want) {
+				t.Errorf("Misfires() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMisfirePolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *MisfirePolicy
+		wantErr bool
+	}{
+		{name: "skip", policy: &MisfirePolicy{}},
+		{name: "fire all", policy: &MisfirePolicy{Mode: MisfireModeFireAll, StartingDeadline: time.Hour, MaxFires: 5}},
+		{name: "unknown mode", policy: &MisfirePolicy{Mode: MisfireModeFireAll + 1}, wantErr: true},
+		{name: "negative mode", policy: &MisfirePolicy{Mode: -1}, wantErr: true},
+		{name: "negative starting deadline", policy: &MisfirePolicy{StartingDeadline: -time.Second}, wantErr: true},
+		{name: "negative max fires", policy: &MisfirePolicy{MaxFires: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,13 +1,22 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"io"
 	"net"
 	"path"
+	"strconv"
 	"time"
 
 	"github.com/KevinWu0904/crond/pkg/logs"
+	"github.com/KevinWu0904/crond/proto/types"
 	"github.com/hashicorp/raft"
 	raftboltdb "github.com/hashicorp/raft-boltdb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -15,18 +24,47 @@ const (
 	raftMaxLogCacheSize         = 500
 	raftNetworkTransportMaxPool = 3
 	raftNetworkTransportTimeout = time.Second * 30
+	raftApplyTimeout            = time.Second * 10
+	raftBarrierTimeout          = time.Second * 30
 )
 
+// raftAddr is the address advertised to raft peers, it is not resolved until peers dial it.
+type raftAddr string
+
+// Network implements net.Addr interface.
+func (a raftAddr) Network() string {
+	return "tcp"
+}
+
+// String implements net.Addr interface.
+func (a raftAddr) String() string {
+	return string(a)
+}
+
 // RaftStreamLayer implements raft low-level network transport.
 type RaftStreamLayer struct {
 	net.Listener
+
+	advertise net.Addr
 }
 
-// NewRaftStreamLayer creates RaftStreamLayer.
-func NewRaftStreamLayer(listener net.Listener) *RaftStreamLayer {
-	return &RaftStreamLayer{
-		listener,
+// NewRaftStreamLayer creates RaftStreamLayer, peers reach the current node by advertise, an empty advertise falls
+// back to the listener address.
+func NewRaftStreamLayer(listener net.Listener, advertise string) *RaftStreamLayer {
+	layer := &RaftStreamLayer{
+		Listener:  listener,
+		advertise: listener.Addr(),
 	}
+	if advertise != "" {
+		layer.advertise = raftAddr(advertise)
+	}
+
+	return layer
+}
+
+// Addr returns the advertised address, raft transport takes it as the local address.
+func (t *RaftStreamLayer) Addr() net.Addr {
+	return t.advertise
 }
 
 // Dial connects to the address on the named network.
@@ -35,10 +73,30 @@ func (t *RaftStreamLayer) Dial(address raft.ServerAddress, timeout time.Duration
 	return dialer.Dial("tcp", string(address))
 }
 
+// Peer represents a server in raft configuration.
+type Peer struct {
+	ID       string
+	Address  string
+	Suffrage raft.ServerSuffrage
+	Leader   bool
+}
+
+// ToProto converts Peer into types.Peer.
+func (p *Peer) ToProto() *types.Peer {
+	return &types.Peer{
+		Id:       p.ID,
+		Address:  p.Address,
+		Suffrage: types.PeerSuffrage(p.Suffrage),
+		Leader:   p.Leader,
+	}
+}
+
 // RaftLayer represents crond raft consensus layer.
 type RaftLayer struct {
-	bootstrap bool
-	underlay  *raft.Raft
+	bootstrap     bool
+	existingState bool
+	underlay      *raft.Raft
+	fsm           *JobFSM
 
 	rc            *raft.Config
 	snapshotStore raft.SnapshotStore
@@ -47,8 +105,8 @@ type RaftLayer struct {
 	transport     raft.Transport
 }
 
-// NewRaftLayer creates crond RaftLayer.
-func NewRaftLayer(c *Config, listener net.Listener) *RaftLayer {
+// NewRaftLayer creates crond RaftLayer, all committed logs will be applied to fsm.
+func NewRaftLayer(c *Config, listener net.Listener, fsm *JobFSM) *RaftLayer {
 	rc := raft.DefaultConfig()
 	rc.LogOutput = logs.GetRaftWriter()
 	rc.LocalID = raft.ServerID(c.RaftNode)
@@ -83,17 +141,24 @@ func NewRaftLayer(c *Config, listener net.Listener) *RaftLayer {
 			logs.Fatal("NewRaftLayer failed to create log store: err=%v", err)
 		}
 	}
-	transport := raft.NewNetworkTransport(NewRaftStreamLayer(listener), raftNetworkTransportMaxPool,
+	existingState, err := raft.HasExistingState(logStore, stableStore, snapshotStore)
+	if err != nil {
+		logs.Fatal("NewRaftLayer failed to check existing state: err=%v", err)
+	}
+
+	transport := raft.NewNetworkTransport(NewRaftStreamLayer(listener, c.RaftAdvertise), raftNetworkTransportMaxPool,
 		raftNetworkTransportTimeout, logs.GetRaftWriter())
 
-	underlay, err := raft.NewRaft(rc, nil, logStore, stableStore, snapshotStore, transport)
+	underlay, err := raft.NewRaft(rc, fsm, logStore, stableStore, snapshotStore, transport)
 	if err != nil {
 		logs.Fatal("NewRaftLayer failed to create raft instance: err=%v", err)
 	}
 
 	return &RaftLayer{
 		bootstrap:     c.RaftBootstrap,
+		existingState: existingState,
 		underlay:      underlay,
+		fsm:           fsm,
 		rc:            rc,
 		snapshotStore: snapshotStore,
 		stableStore:   stableStore,
@@ -102,9 +167,9 @@ func NewRaftLayer(c *Config, listener net.Listener) *RaftLayer {
 	}
 }
 
-// Run starts raft layer.
+// Run starts raft layer, it bootstraps a single node cluster if required and the node has no existing state.
 func (l *RaftLayer) Run() {
-	if l.bootstrap {
+	if l.bootstrap && !l.existingState {
 		configuration := raft.Configuration{
 			Servers: []raft.Server{
 				{
@@ -117,3 +182,212 @@ func (l *RaftLayer) Run() {
 		l.underlay.BootstrapCluster(configuration)
 	}
 }
+
+// Apply commits the command through raft consensus and returns the response from JobFSM. The trace context of ctx
+// is carried by the command, so JobFSM applies on all nodes join the same trace.
+func (l *RaftLayer) Apply(ctx context.Context, cmd *Command) (resp interface{}, err error) {
+	ctx, span := tracer.Start(ctx, "RaftLayer.Apply", trace.WithAttributes(attribute.Int("crond.command_type", int(cmd.Type))))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	carrier := mapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) > 0 {
+		cmd.Trace = carrier
+	}
+
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	future := l.underlay.Apply(data, raftApplyTimeout)
+	if err := future.Error(); err != nil {
+		return nil, err
+	}
+
+	resp = future.Response()
+	if err, ok := resp.(error); ok {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// IsLeader reports whether the current node is raft leader.
+func (l *RaftLayer) IsLeader() bool {
+	return l.underlay.State() == raft.Leader
+}
+
+// LeaderAddr returns the current raft leader address, it is empty if there is no leader.
+func (l *RaftLayer) LeaderAddr() raft.ServerAddress {
+	return l.underlay.Leader()
+}
+
+// LeaderCh delivers true when the current node acquires leadership and false when it loses leadership.
+func (l *RaftLayer) LeaderCh() <-chan bool {
+	return l.underlay.LeaderCh()
+}
+
+// Barrier blocks until all preceding logs have been applied to JobFSM.
+func (l *RaftLayer) Barrier() error {
+	return l.underlay.Barrier(raftBarrierTimeout).Error()
+}
+
+// ExistingState reports whether the node had raft state when it started, so it has been bootstrapped or joined.
+func (l *RaftLayer) ExistingState() bool {
+	return l.existingState
+}
+
+// LocalID returns the raft server ID of the current node.
+func (l *RaftLayer) LocalID() raft.ServerID {
+	return l.rc.LocalID
+}
+
+// LocalAddr returns the raft address advertised by the current node.
+func (l *RaftLayer) LocalAddr() raft.ServerAddress {
+	return l.transport.LocalAddr()
+}
+
+// AddVoter adds a voting server to the cluster, it only works on raft leader.
+func (l *RaftLayer) AddVoter(id, addr string) error {
+	return l.underlay.AddVoter(raft.ServerID(id), raft.ServerAddress(addr), 0, raftApplyTimeout).Error()
+}
+
+// AddNonvoter adds a server receiving logs without voting to the cluster, it only works on raft leader.
+func (l *RaftLayer) AddNonvoter(id, addr string) error {
+	return l.underlay.AddNonvoter(raft.ServerID(id), raft.ServerAddress(addr), 0, raftApplyTimeout).Error()
+}
+
+// RemoveServer removes a server from the cluster, it only works on raft leader.
+func (l *RaftLayer) RemoveServer(id string) error {
+	return l.underlay.RemoveServer(raft.ServerID(id), 0, raftApplyTimeout).Error()
+}
+
+// Peers returns the servers in the latest raft configuration known by the current node.
+func (l *RaftLayer) Peers() ([]*Peer, error) {
+	future := l.underlay.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return nil, err
+	}
+
+	leader := l.underlay.Leader()
+	servers := future.Configuration().Servers
+	peers := make([]*Peer, 0, len(servers))
+	for _, server := range servers {
+		peers = append(peers, &Peer{
+			ID:       string(server.ID),
+			Address:  string(server.Address),
+			Suffrage: server.Suffrage,
+			Leader:   server.Address == leader,
+		})
+	}
+
+	return peers, nil
+}
+
+// RaftStatus represents the raft state of the current node.
+type RaftStatus struct {
+	Role         raft.RaftState
+	Term         uint64
+	CommitIndex  uint64
+	AppliedIndex uint64
+	LastContact  time.Time // Last time the leader contacted the current node, it is zero on raft leader.
+	LeaderAddr   raft.ServerAddress
+
+	fsmIndex     uint64 // Raft log index of the latest command applied by JobFSM.
+	commandIndex uint64 // Raft log index of the latest committed command, zero if it has been compacted.
+}
+
+// Status reads the raft state of the current node.
+func (l *RaftLayer) Status() *RaftStatus {
+	stats := l.underlay.Stats()
+	term, _ := strconv.ParseUint(stats["term"], 10, 64)
+	commitIndex, _ := strconv.ParseUint(stats["commit_index"], 10, 64)
+	appliedIndex, _ := strconv.ParseUint(stats["applied_index"], 10, 64)
+
+	status := &RaftStatus{
+		Role:         l.underlay.State(),
+		Term:         term,
+		CommitIndex:  commitIndex,
+		AppliedIndex: appliedIndex,
+		LeaderAddr:   l.underlay.Leader(),
+		fsmIndex:     l.fsm.AppliedIndex(),
+		commandIndex: l.commandIndex(commitIndex),
+	}
+	if status.Role != raft.Leader {
+		status.LastContact = l.underlay.LastContact()
+	}
+
+	return status
+}
+
+// commandIndex finds the latest command at or before index. Raft logs such as no-ops and configuration changes are
+// never applied to JobFSM, so they are skipped. A compacted log has been applied before it was snapshotted, so zero
+// is returned.
+func (l *RaftLayer) commandIndex(index uint64) uint64 {
+	var log raft.Log
+	for ; index > 0; index-- {
+		if err := l.logStore.GetLog(index, &log); err != nil {
+			return 0
+		}
+		if log.Type == raft.LogCommand {
+			return index
+		}
+	}
+
+	return 0
+}
+
+// ready reports whether the node knows raft leader and JobFSM has applied all committed logs. Raft counts logs as
+// applied once they are handed to JobFSM, so the index of JobFSM itself is checked as well.
+func (s *RaftStatus) ready() error {
+	if s.Role == raft.Shutdown || s.LeaderAddr == "" {
+		return ErrNoLeader
+	}
+
+	if s.AppliedIndex < s.CommitIndex || s.fsmIndex < s.commandIndex {
+		return ErrNotCaughtUp
+	}
+
+	return nil
+}
+
+// TransferLeadership hands leadership over to the most up-to-date voter, so the cluster does not wait for an election
+// timeout. It does nothing if the current node is not raft leader or there is no other voter.
+func (l *RaftLayer) TransferLeadership() error {
+	if !l.IsLeader() {
+		return nil
+	}
+
+	peers, err := l.Peers()
+	if err != nil {
+		return err
+	}
+
+	for _, peer := range peers {
+		if peer.Suffrage == raft.Voter && raft.ServerID(peer.ID) != l.rc.LocalID {
+			return l.underlay.LeadershipTransfer().Error()
+		}
+	}
+
+	return nil
+}
+
+// Shutdown stops raft layer and closes its persistent stores.
+func (l *RaftLayer) Shutdown() error {
+	err := l.underlay.Shutdown().Error()
+
+	if closer, ok := l.stableStore.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
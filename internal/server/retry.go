@@ -0,0 +1,167 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/KevinWu0904/crond/proto/types"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	defaultRetryInitialBackoff = time.Second
+	defaultRetryMultiplier     = 2.0
+
+	// retryMaxBackoff is the hard ceiling of backoff, exponential growth overflows time.Duration otherwise.
+	retryMaxBackoff = time.Hour * 24
+)
+
+// FailureClass classifies failed runs, RetryPolicy decides whether to retry by it.
+type FailureClass int8
+
+const (
+	// FailureClassUnspecified means the run did not fail.
+	FailureClassUnspecified FailureClass = iota
+	// FailureClassError means the executor failed to get any response, e.g. command not found or connection refused.
+	FailureClassError
+	// FailureClassFailed means the executor got a response which does not satisfy success rules.
+	FailureClassFailed
+	// FailureClassTimedOut means the run exceeded the job timeout.
+	FailureClassTimedOut
+)
+
+// RetryPolicy defines how CronDispatcher retries failed runs of a job.
+// MaxAttempts counts the first attempt, so values less than 2 disable retries. Zero InitialBackoff and Multiplier
+// fall back to 1s and 2.0, zero MaxBackoff falls back to 24h which is also the ceiling. Jitter randomizes each
+// backoff by +/- Jitter fraction. Empty RetryOn retries all failure classes.
+type RetryPolicy struct {
+	MaxAttempts    int32          `json:"max_attempts"`
+	InitialBackoff time.Duration  `json:"initial_backoff"`
+	Multiplier     float64        `json:"multiplier"`
+	MaxBackoff     time.Duration  `json:"max_backoff"`
+	Jitter         float64        `json:"jitter"`
+	RetryOn        []FailureClass `json:"retry_on,omitempty"`
+}
+
+// NewRetryPolicyFromProto converts types.RetryPolicy into RetryPolicy.
+func NewRetryPolicyFromProto(pb *types.RetryPolicy) *RetryPolicy {
+	if pb == nil {
+		return nil
+	}
+
+	p := &RetryPolicy{
+		MaxAttempts:    pb.GetMaxAttempts(),
+		InitialBackoff: pb.GetInitialBackoff().AsDuration(),
+		Multiplier:     pb.GetMultiplier(),
+		MaxBackoff:     pb.GetMaxBackoff().AsDuration(),
+		Jitter:         pb.GetJitter(),
+	}
+	for _, c := range pb.GetRetryOn() {
+		p.RetryOn = append(p.RetryOn, FailureClass(c))
+	}
+
+	return p
+}
+
+// ToProto converts RetryPolicy into types.RetryPolicy.
+func (p *RetryPolicy) ToProto() *types.RetryPolicy {
+	pb := &types.RetryPolicy{
+		MaxAttempts:    p.MaxAttempts,
+		InitialBackoff: durationpb.New(p.InitialBackoff),
+		Multiplier:     p.Multiplier,
+		MaxBackoff:     durationpb.New(p.MaxBackoff),
+		Jitter:         p.Jitter,
+	}
+	for _, c := range p.RetryOn {
+		pb.RetryOn = append(pb.RetryOn, types.FailureClass(c))
+	}
+
+	return pb
+}
+
+// Clone returns a deep copy of RetryPolicy.
+func (p *RetryPolicy) Clone() *RetryPolicy {
+	clone := *p
+	clone.RetryOn = append([]FailureClass(nil), p.RetryOn...)
+	return &clone
+}
+
+// Validate checks whether RetryPolicy is well-formed.
+func (p *RetryPolicy) Validate() error {
+	switch {
+	case p.MaxAttempts < 0:
+		return errors.New("retry max_attempts must not be negative")
+	case p.InitialBackoff < 0 || p.MaxBackoff < 0:
+		return errors.New("retry backoff must not be negative")
+	case p.InitialBackoff > retryMaxBackoff || p.MaxBackoff > retryMaxBackoff:
+		return fmt.Errorf("retry backoff must not exceed %v", retryMaxBackoff)
+	case math.IsNaN(p.Multiplier) || math.IsInf(p.Multiplier, 0):
+		return errors.New("retry multiplier must be finite")
+	case p.Multiplier != 0 && p.Multiplier < 1:
+		return errors.New("retry multiplier must not be less than 1")
+	case math.IsNaN(p.Jitter) || p.Jitter < 0 || p.Jitter > 1:
+		return errors.New("retry jitter must be in range [0, 1]")
+	}
+
+	for _, c := range p.RetryOn {
+		if c <= FailureClassUnspecified || c > FailureClassTimedOut {
+			return errors.New("retry retry_on contains unknown failure class")
+		}
+	}
+
+	return nil
+}
+
+// ShouldRetry reports whether another attempt should be made after the finished run.
+func (p *RetryPolicy) ShouldRetry(run *JobRun) bool {
+	if p == nil || run.FailureClass == FailureClassUnspecified || run.Attempt >= p.MaxAttempts {
+		return false
+	}
+
+	if len(p.RetryOn) == 0 {
+		return true
+	}
+
+	for _, c := range p.RetryOn {
+		if c == run.FailureClass {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Backoff returns the duration to wait before the next attempt, attempt is the number of the failed attempt.
+func (p *RetryPolicy) Backoff(attempt int32) time.Duration {
+	initial, multiplier := p.InitialBackoff, p.Multiplier
+	if initial == 0 {
+		initial = defaultRetryInitialBackoff
+	}
+	if multiplier == 0 {
+		multiplier = defaultRetryMultiplier
+	}
+
+	maxBackoff := p.MaxBackoff
+	if maxBackoff == 0 {
+		maxBackoff = retryMaxBackoff
+	}
+
+	// Compare in float64, the product may be far beyond time.Duration range or even +Inf.
+	backoff := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if backoff > float64(maxBackoff) {
+		backoff = float64(maxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		// nolint:gosec
+		backoff *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	if backoff > float64(retryMaxBackoff) {
+		backoff = float64(retryMaxBackoff)
+	}
+
+	return time.Duration(backoff)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *RetryPolicy
+		wantErr bool
+	}{
+		{name: "defaults", policy: &RetryPolicy{MaxAttempts: 3}},
+		{name: "custom", policy: &RetryPolicy{MaxAttempts: 3, Multiplier: 1.5, Jitter: 0.5, MaxBackoff: time.Hour}},
+		{name: "multiplier less than 1", policy: &RetryPolicy{Multiplier: 0.5}, wantErr: true},
+		{name: "multiplier NaN", policy: &RetryPolicy{Multiplier: math.NaN()}, wantErr: true},
+		{name: "multiplier +Inf", policy: &RetryPolicy{Multiplier: math.Inf(1)}, wantErr: true},
+		{name: "multiplier -Inf", policy: &RetryPolicy{Multiplier: math.Inf(-1)}, wantErr: true},
+		{name: "jitter NaN", policy: &RetryPolicy{Jitter: math.NaN()}, wantErr: true},
+		{name: "jitter +Inf", policy: &RetryPolicy{Jitter: math.Inf(1)}, wantErr: true},
+		{name: "jitter -Inf", policy: &RetryPolicy{Jitter: math.Inf(-1)}, wantErr: true},
+		{name: "backoff over ceiling", policy: &RetryPolicy{MaxBackoff: retryMaxBackoff + 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *RetryPolicy
+		attempt int32
+		want    time.Duration
+	}{
+		{name: "first attempt", policy: &RetryPolicy{}, attempt: 1, want: time.Second},
+		{name: "exponential", policy: &RetryPolicy{}, attempt: 4, want: time.Second * 8},
+		{name: "max backoff", policy: &RetryPolicy{MaxBackoff: time.Minute}, attempt: 10, want: time.Minute},
+		{name: "overflows Duration", policy: &RetryPolicy{}, attempt: 64, want: retryMaxBackoff},
+		{name: "overflows float64", policy: &RetryPolicy{}, attempt: 2000, want: retryMaxBackoff},
+		{name: "max attempt", policy: &RetryPolicy{Multiplier: 10}, attempt: math.MaxInt32, want: retryMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Backoff(tt.attempt); got != tt.want {
+				t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoffJitterCeiling(t *testing.T) {
+	policy := &RetryPolicy{Jitter: 1}
+	for i := 0; i < 1000; i++ {
+		if got := policy.Backoff(2000); got < 0 || got > retryMaxBackoff {
+			t.Fatalf("Backoff(2000) = %v, want in [0, %v]", got, retryMaxBackoff)
+		}
+	}
+}
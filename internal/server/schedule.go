@@ -0,0 +1,279 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	// Embed the IANA time zone database, so time_zone works on hosts without zoneinfo files.
+	_ "time/tzdata"
+
+	"github.com/KevinWu0904/crond/proto/types"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	allHours = 1<<24 - 1
+	// wallClockMaxScan bounds the wall clock fires skipped before an instant after the given time is found, they are
+	// only skipped in the repeated hour, which has at most 3600 fires.
+	wallClockMaxScan = 3601
+)
+
+// scheduleFields names the fields of cron expressions accepted by cronParser in order.
+var scheduleFields = []string{"second", "minute", "hour", "day_of_month", "month", "day_of_week"}
+
+// ScheduleError describes a malformed schedule. Field is the malformed part, which is "time_zone", "descriptor",
+// one of scheduleFields, or empty if the expression is malformed as a whole. Position and Length locate the
+// malformed part in bytes of the cron expression, Length is zero if it is not located in the expression.
+type ScheduleError struct {
+	Field    string
+	Position int
+	Length   int
+	Err      error
+}
+
+// Error implements error interface.
+func (e *ScheduleError) Error() string {
+	if e.Field == "" {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%s at position %d: %v", e.Field, e.Position, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ScheduleError) Unwrap() error {
+	return e.Err
+}
+
+// ToProto converts ScheduleError into types.ScheduleError.
+func (e *ScheduleError) ToProto() *types.ScheduleError {
+	return &types.ScheduleError{
+		Message:  e.Err.Error(),
+		Field:    e.Field,
+		Position: int32(e.Position),
+		Length:   int32(e.Length),
+	}
+}
+
+// parseSchedule parses the cron expression in the IANA time zone with the parser CronDispatcher uses, an empty
+// timeZone keeps the zone of the expression, which is UTC unless it has a CRON_TZ prefix. The server local zone is
+// never used, so every node fires a job at the same instants. Errors are always *ScheduleError.
+func parseSchedule(expression, timeZone string) (cron.Schedule, error) {
+	hasZone := strings.HasPrefix(expression, "TZ=") || strings.HasPrefix(expression, "CRON_TZ=")
+
+	spec := expression
+	switch {
+	case timeZone != "" && hasZone:
+		return nil, &ScheduleError{
+			Field: "time_zone",
+			Err:   errors.New("time zone must not be set by both time_zone and cron_expression"),
+		}
+	case timeZone != "":
+		if _, err := time.LoadLocation(timeZone); err != nil {
+			return nil, &ScheduleError{Field: "time_zone", Err: err}
+		}
+		spec = "CRON_TZ=" + timeZone + " " + expression
+	case !hasZone:
+		spec = "CRON_TZ=UTC " + expression
+	}
+
+	schedule, err := cronParser.Parse(spec)
+	if err != nil {
+		return nil, locateScheduleError(expression, err)
+	}
+
+	specSchedule, ok := schedule.(*cron.SpecSchedule)
+	if !ok || specSchedule.Hour&allHours == allHours {
+		return schedule, nil
+	}
+
+	utc := *specSchedule
+	utc.Location = time.UTC
+	return &wallClockSchedule{spec: specSchedule, utc: &utc}, nil
+}
+
+// locateScheduleError finds the malformed part of the expression. Each field is parsed alone with the other fields
+// replaced by wildcards, so the error of the first malformed field is reported.
+func locateScheduleError(expression string, err error) *ScheduleError {
+	fields, positions := splitScheduleFields(expression)
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "TZ=") || strings.HasPrefix(fields[0], "CRON_TZ=")) {
+		if _, tzErr := cronParser.Parse(fields[0] + " * * * * * *"); tzErr != nil {
+			return &ScheduleError{Field: "time_zone", Position: positions[0], Length: len(fields[0]), Err: tzErr}
+		}
+		fields, positions = fields[1:], positions[1:]
+	}
+
+	switch {
+	case len(fields) > 0 && strings.HasPrefix(fields[0], "@"):
+		return &ScheduleError{Field: "descriptor", Position: positions[0], Length: len(expression) - positions[0],
+			Err: err}
+	case len(fields) != len(scheduleFields):
+		return &ScheduleError{Err: err}
+	}
+
+	for i := range fields {
+		candidate := make([]string, len(fields))
+		for j := range candidate {
+			candidate[j] = "*"
+		}
+		candidate[i] = fields[i]
+
+		if _, fieldErr := cronParser.Parse(strings.Join(candidate, " ")); fieldErr != nil {
+			return &ScheduleError{Field: scheduleFields[i], Position: positions[i], Length: len(fields[i]),
+				Err: fieldErr}
+		}
+	}
+
+	return &ScheduleError{Err: err}
+}
+
+// splitScheduleFields splits the expression by whitespaces like strings.Fields, it also returns the byte offset of
+// each field.
+func splitScheduleFields(expression string) ([]string, []int) {
+	var fields []string
+	var positions []int
+
+	start := -1
+	for i, r := range expression {
+		switch {
+		case unicode.IsSpace(r) && start >= 0:
+			fields = append(fields, expression[start:i])
+			positions = append(positions, start)
+			start = -1
+		case !unicode.IsSpace(r) && start < 0:
+			start = i
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, expression[start:])
+		positions = append(positions, start)
+	}
+
+	return fields, positions
+}
+
+// previewSchedule returns at most count fire times of the schedule after start.
+func previewSchedule(schedule cron.Schedule, start time.Time, count int) []time.Time {
+	var times []time.Time
+	for t := schedule.Next(start); !t.IsZero() && len(times) < count; t = schedule.Next(t) {
+		times = append(times, t)
+	}
+
+	return times
+}
+
+// wallClockSchedule makes DST transitions well-defined for specs firing at specific hours, which are meant to fire
+// at a wall clock time once a day:
+//   - A fire in the skipped hour is shifted forward by the gap, e.g. 02:30 fires at 03:30 after clocks jump from
+//     02:00 to 03:00, a fire coinciding with the shifted one is merged into it.
+//   - A fire in the repeated hour fires once at the first occurrence.
+//
+// Specs firing at every hour are left to cron.SpecSchedule, they fire by elapsed time: nothing in the skipped hour
+// and twice in the repeated hour, so the interval between fires is always kept.
+type wallClockSchedule struct {
+	spec *cron.SpecSchedule
+	utc  *cron.SpecSchedule // The same spec in UTC, which has no DST, it iterates wall clock times.
+}
+
+// Next implements cron.Schedule interface.
+func (s *wallClockSchedule) Next(t time.Time) time.Time {
+	loc := s.spec.Location
+	local := t.In(loc)
+	wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(),
+		local.Nanosecond(), time.UTC)
+
+	for i := 0; i < wallClockMaxScan; i++ {
+		wall = s.utc.Next(wall)
+		if wall.IsZero() {
+			return wall
+		}
+
+		if next := resolveWallClock(wall, loc); next.After(t) {
+			return next
+		}
+	}
+
+	return time.Time{}
+}
+
+// resolveWallClock returns the instant showing the wall clock time in loc, wall is given in UTC. Unlike time.Date,
+// it always resolves a repeated time to the first occurrence and shifts a skipped time forward by the gap.
+func resolveWallClock(wall time.Time, loc *time.Location) time.Time {
+	// DST transitions are months apart, so at most two offsets apply around the wall clock time.
+	_, before := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, after := wall.Add(24 * time.Hour).In(loc).Zone()
+
+	var valid []time.Time
+	for _, offset := range []int{before, after} {
+		instant := wall.Add(-time.Duration(offset) * time.Second)
+		if sameWallClock(instant.In(loc), wall) {
+			valid = append(valid, instant)
+		}
+	}
+
+	switch len(valid) {
+	case 0:
+		// Skipped: the instant with the offset before the gap is the wall clock time shifted forward by the gap.
+		return wall.Add(-time.Duration(before) * time.Second).In(loc)
+	case 1:
+		return valid[0].In(loc)
+	default:
+		// Repeated, or both offsets are the same.
+		if valid[1].Before(valid[0]) {
+			return valid[1].In(loc)
+		}
+		return valid[0].In(loc)
+	}
+}
+
+func sameWallClock(local, wall time.Time) bool {
+	y1, m1, d1 := local.Date()
+	y2, m2, d2 := wall.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2 &&
+		local.Hour() == wall.Hour() && local.Minute() == wall.Minute() && local.Second() == wall.Second()
+}
+
+// onceSchedule fires once at the time.
+type onceSchedule time.Time
+
+// Next implements cron.Schedule interface.
+func (s onceSchedule) Next(t time.Time) time.Time {
+	if at := time.Time(s); t.Before(at) {
+		return at
+	}
+
+	return time.Time{}
+}
+
+// intervalSchedule fires every interval since anchor, including anchor itself.
+type intervalSchedule struct {
+	anchor   time.Time
+	interval time.Duration
+}
+
+// Next implements cron.Schedule interface.
+func (s *intervalSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.anchor) {
+		return s.anchor
+	}
+
+	return s.anchor.Add((t.Sub(s.anchor)/s.interval + 1) * s.interval)
+}
+
+// endingSchedule stops the schedule after end, a fire at end is kept.
+type endingSchedule struct {
+	schedule cron.Schedule
+	end      time.Time
+}
+
+// Next implements cron.Schedule interface.
+func (s *endingSchedule) Next(t time.Time) time.Time {
+	if next := s.schedule.Next(t); !next.After(s.end) {
+		return next
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+// America/New_York springs forward at 2026-03-08 02:00 EST and falls back at 2026-11-01 02:00 EDT.
+const dstTimeZone = "America/New_York"
+
+func TestScheduleDST(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		after      string
+		want       []string
+	}{
+		{
+			name:       "spring forward shifts skipped fire by the gap",
+			expression: "0 30 2 * * *",
+			after:      "2026-03-08T00:00:00-05:00",
+			want:       []string{"2026-03-08T03:30:00-04:00", "2026-03-09T02:30:00-04:00"},
+		},
+		{
+			name:       "spring forward merges shifted fire into coinciding fire",
+			expression: "0 30 2,3 * * *",
+			after:      "2026-03-08T00:00:00-05:00",
+			want:       []string{"2026-03-08T03:30:00-04:00", "2026-03-09T02:30:00-04:00", "2026-03-09T03:30:00-04:00"},
+		},
+		{
+			name:       "spring forward keeps fire before the gap",
+			expression: "0 30 1 * * *",
+			after:      "2026-03-08T00:00:00-05:00",
+			want:       []string{"2026-03-08T01:30:00-05:00", "2026-03-09T01:30:00-04:00"},
+		},
+		{
+			name:       "spring forward skips hourly fire in the gap",
+			expression: "0 30 * * * *",
+			after:      "2026-03-08T01:00:00-05:00",
+			want:       []string{"2026-03-08T01:30:00-05:00", "2026-03-08T03:30:00-04:00"},
+		},
+		{
+			name:       "fall back fires repeated time once",
+			expression: "0 30 1 * * *",
+			after:      "2026-11-01T00:00:00-04:00",
+			want:       []string{"2026-11-01T01:30:00-04:00", "2026-11-02T01:30:00-05:00"},
+		},
+		{
+			name:       "fall back keeps fire after the repeated hour",
+			expression: "0 30 2 * * *",
+			after:      "2026-11-01T00:00:00-04:00",
+			want:       []string{"2026-11-01T02:30:00-05:00", "2026-11-02T02:30:00-05:00"},
+		},
+		{
+			name:       "fall back fires hourly fire twice",
+			expression: "0 30 * * * *",
+			after:      "2026-11-01T01:00:00-04:00",
+			want:       []string{"2026-11-01T01:30:00-04:00", "2026-11-01T01:30:00-05:00", "2026-11-01T02:30:00-05:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseSchedule(tt.expression, dstTimeZone)
+			if err != nil {
+				t.Fatalf("parseSchedule failed: err=%v", err)
+			}
+
+			got := previewSchedule(schedule, mustParseTime(t, tt.after), len(tt.want))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d fires, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if w := mustParseTime(t, want); !got[i].Equal(w) {
+					t.Errorf("fire %d = %v, want %v", i, got[i], w)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveWallClock(t *testing.T) {
+	loc, err := time.LoadLocation(dstTimeZone)
+	if err != nil {
+		t.Fatalf("LoadLocation failed: err=%v", err)
+	}
+
+	tests := []struct {
+		name string
+		wall string
+		want string
+	}{
+		{name: "standard time", wall: "2026-01-15T12:00:00Z", want: "2026-01-15T12:00:00-05:00"},
+		{name: "daylight time", wall: "2026-06-15T12:00:00Z", want: "2026-06-15T12:00:00-04:00"},
+		{name: "spring forward skipped", wall: "2026-03-08T02:30:00Z", want: "2026-03-08T03:30:00-04:00"},
+		{name: "spring forward gap start", wall: "2026-03-08T02:00:00Z", want: "2026-03-08T03:00:00-04:00"},
+		{name: "spring forward after gap", wall: "2026-03-08T03:00:00Z", want: "2026-03-08T03:00:00-04:00"},
+		{name: "fall back repeated", wall: "2026-11-01T01:30:00Z", want: "2026-11-01T01:30:00-04:00"},
+		{name: "fall back after repeated", wall: "2026-11-01T02:00:00Z", want: "2026-11-01T02:00:00-05:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveWallClock(mustParseTime(t, tt.wall), loc)
+			if want := mustParseTime(t, tt.want); !got.Equal(want) {
+				t.Errorf("resolveWallClock() = %v, want %v", got, want)
+			}
+			if got.Location() != loc {
+				t.Errorf("resolveWallClock() location = %v, want %v", got.Location(), loc)
+			}
+		})
+	}
+}
+
+func TestParseScheduleDefaultsToUTC(t *testing.T) {
+	local := time.Local
+	defer func() { time.Local = local }()
+
+	var err error
+	if time.Local, err = time.LoadLocation(dstTimeZone); err != nil {
+		t.Fatalf("LoadLocation failed: err=%v", err)
+	}
+
+	schedule, err := parseSchedule("0 0 12 * * *", "")
+	if err != nil {
+		t.Fatalf("parseSchedule failed: err=%v", err)
+	}
+
+	got := schedule.Next(mustParseTime(t, "2026-06-15T00:00:00Z"))
+	if want := mustParseTime(t, "2026-06-15T12:00:00Z"); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
+
+func mustParseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("time.Parse failed: value=%s, err=%v", value, err)
+	}
+	return parsed
+}
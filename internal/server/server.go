@@ -5,30 +5,74 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/KevinWu0904/crond/pkg/logs"
 	"github.com/KevinWu0904/crond/proto/types"
 	ginzap "github.com/gin-contrib/zap"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/soheilhy/cmux"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// dispatcherStopTimeout is the maximum duration to drain in-flight runs after losing raft leadership.
+	dispatcherStopTimeout = time.Second * 30
+	// raftJoinRetryInterval is the interval between rounds of joining the cluster through all join addresses.
+	raftJoinRetryInterval = time.Second * 5
+	// raftJoinTimeout is the maximum duration of a single join request.
+	raftJoinTimeout = time.Second * 15
+	// raftBarrierRetryInterval is the interval between raft barriers before CronDispatcher starts on a new leader.
+	raftBarrierRetryInterval = time.Second
+	// healthCheckInterval is the interval of updating gRPC health serving status from readiness.
+	healthCheckInterval = time.Second
+	// crondServiceName is the full name of crond gRPC service, its health can be checked separately.
+	crondServiceName = "types.Crond"
 )
 
 // Server represents crond server.
 type Server struct {
-	c            *Config
-	grpcServer   *grpc.Server
-	httpServer   *http.Server
-	raftLayer    *RaftLayer
-	mux          cmux.CMux
-	grpcListener net.Listener
-	httpListener net.Listener
-	raftListener net.Listener
+	c              *Config
+	grpcServer     *grpc.Server
+	healthServer   *health.Server
+	httpServer     *http.Server
+	raftLayer      *RaftLayer
+	forwarder      *LeaderForwarder
+	fsm            *JobFSM
+	events         *EventHub
+	dispatcher     *CronDispatcher
+	clusterService *ClusterService
+	mux            cmux.CMux
+	grpcListener   net.Listener
+	httpListener   net.Listener
+	raftListener   net.Listener
+	shutdownCh     chan struct{}
+	shutdownMu     sync.Mutex // Makes closing shutdownCh atomic with starting CronDispatcher.
+
+	shutdownTracing func(ctx context.Context) error // Flushes pending spans.
 }
 
 // NewServer creates crond Server.
 func NewServer(c *Config) (*Server, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Raft metrics must be bridged before raft starts emitting them.
+	if err := InitRaftMetrics(); err != nil {
+		return nil, err
+	}
+
+	shutdownTracing, err := InitTracing(c)
+	if err != nil {
+		return nil, err
+	}
+
 	listener, err := net.Listen("tcp", ":"+strconv.Itoa(c.ServerPort))
 	if err != nil {
 		return nil, err
@@ -37,38 +81,71 @@ func NewServer(c *Config) (*Server, error) {
 	// Serve multiple protocols on the same listener.
 	mux := cmux.New(listener)
 
-	grpcListener := mux.Match(cmux.HTTP2HeaderField("content-type", "application/grpc"))
+	grpcListener := mux.MatchWithWriters(cmux.HTTP2MatchHeaderFieldSendSettings("content-type", "application/grpc"))
 	httpListener := mux.Match(cmux.HTTP1Fast())
 	raftListener := mux.Match(cmux.Any())
 
+	// New crond raft layer.
+	events := NewEventHub(c.WatchHistoryMax)
+	fsm := NewJobFSM(c.RunHistoryMax, events)
+	raftLayer := NewRaftLayer(c, raftListener, fsm)
+	jobService := NewJobService(raftLayer, fsm, events)
+	dispatcher := NewCronDispatcher(c.RaftNode, jobService, jobService, c.CompletedJobTTL)
+	fsm.AddListener(dispatcher)
+	jobService.SetDispatcher(dispatcher)
+
+	// Export metrics of crond state along with process-wide metrics, state is registered per server since it belongs
+	// to this JobFSM and CronDispatcher.
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewStateCollector(fsm, dispatcher))
+	gatherer := prometheus.Gatherers{prometheus.DefaultGatherer, registry}
+
 	// New crond gRPC server.
-	grpcServer := grpc.NewServer()
-	grpcService := NewCrondGRPCService()
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor(), UnaryServerTraceKVs, UnaryServerMetrics),
+		grpc.ChainStreamInterceptor(otelgrpc.StreamServerInterceptor(), StreamServerTraceKVs, StreamServerMetrics),
+	)
+	forwarder := NewLeaderForwarder(raftLayer)
+	clusterService := NewClusterService(raftLayer, dispatcher)
+	grpcService := NewCrondGRPCService(jobService, clusterService, forwarder)
 	types.RegisterCrondServer(grpcServer, grpcService)
 
+	// Serving status of gRPC health service follows readiness, see monitorHealth.
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthServer.SetServingStatus(crondServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
 	// New crond HTTP server.
 	router := gin.Default()
 	gin.DefaultWriter = logs.GetGinWriter()
 	gin.DefaultErrorWriter = logs.GetGinErrorWriter()
 	router.Use(ginzap.Ginzap(logs.GetLogger(), "2006-01-02T15:04:05.000Z0700", false))
 	router.Use(ginzap.RecoveryWithZap(logs.GetLogger(), true))
+	router.Use(HTTPMetrics)
 
-	httpService := NewCrondHTTPService()
+	httpService := NewCrondHTTPService(jobService, clusterService, raftLayer, gatherer)
 	RegisterCrondHTTPServer(router, httpService)
 	httpServer := &http.Server{Handler: router}
 
-	// New crond raft layer.
-	raftLayer := NewRaftLayer(c, raftListener)
-
 	return &Server{
-		c:            c,
-		grpcServer:   grpcServer,
-		httpServer:   httpServer,
-		raftLayer:    raftLayer,
-		mux:          mux,
-		grpcListener: grpcListener,
-		httpListener: httpListener,
-		raftListener: raftListener,
+		c:              c,
+		grpcServer:     grpcServer,
+		healthServer:   healthServer,
+		httpServer:     httpServer,
+		raftLayer:      raftLayer,
+		forwarder:      forwarder,
+		fsm:            fsm,
+		events:         events,
+		dispatcher:     dispatcher,
+		clusterService: clusterService,
+		mux:            mux,
+		grpcListener:   grpcListener,
+		httpListener:   httpListener,
+		raftListener:   raftListener,
+		shutdownCh:     make(chan struct{}),
+
+		shutdownTracing: shutdownTracing,
 	}, nil
 }
 
@@ -77,18 +154,187 @@ func (s *Server) Run() {
 	go s.grpcServer.Serve(s.grpcListener)
 	go s.httpServer.Serve(s.httpListener)
 	go s.raftLayer.Run()
+	go s.monitorLeadership()
+	go s.monitorHealth()
+
+	// A node with raft state has been bootstrapped or joined, it catches up with the cluster by itself.
+	if len(s.c.RaftJoin) > 0 && !s.c.RaftBootstrap && !s.raftLayer.ExistingState() {
+		go s.joinCluster()
+	}
 
 	logs.Info("CronD server starting...: port=%d", s.c.ServerPort)
 	s.mux.Serve()
 }
 
-// GracefulShutdown stops crond server gracefully.
+// GracefulShutdown stops crond server gracefully. The leader drains in-flight runs while it can still record them,
+// then hands leadership over to a follower before leaving, so the cluster does not wait for an election timeout.
 func (s *Server) GracefulShutdown() {
+	// Once shutdownCh is closed CronDispatcher will not be started, so the drain below can not be bypassed.
+	s.shutdownMu.Lock()
+	close(s.shutdownCh)
+	s.shutdownMu.Unlock()
+
+	// Stop receiving new traffic from health checking load balancers first.
+	s.healthServer.Shutdown()
+
+	// Watch streams never end by themselves, close them so that gRPC and HTTP servers can drain.
+	s.events.Close()
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), s.c.ShutdownDrainTimeout)
+	if err := s.dispatcher.Stop(drainCtx); err != nil {
+		logs.Error("GracefulShutdown failed to drain in-flight runs: err=%v", err)
+	}
+	cancelDrain()
+
+	if err := s.raftLayer.TransferLeadership(); err != nil {
+		logs.Error("GracefulShutdown failed to transfer raft leadership: err=%v", err)
+	}
+
+	// Requests still being served are forwarded to the new leader.
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
 	s.grpcServer.GracefulStop()
 	s.httpServer.Shutdown(ctx)
+	s.forwarder.Close()
+
+	if err := s.raftLayer.Shutdown(); err != nil {
+		logs.Error("GracefulShutdown failed to shutdown raft layer: err=%v", err)
+	}
+
+	if err := s.shutdownTracing(ctx); err != nil {
+		logs.Error("GracefulShutdown failed to flush traces: err=%v", err)
+	}
 
 	logs.Info("CronD server shutdown gracefully")
 }
+
+// monitorLeadership runs CronDispatcher only while the current node is raft leader.
+func (s *Server) monitorLeadership() {
+	for {
+		select {
+		case isLeader := <-s.raftLayer.LeaderCh():
+			if isLeader {
+				s.startDispatcher()
+			} else {
+				s.stopDispatcher()
+			}
+		case <-s.shutdownCh:
+			return
+		}
+	}
+}
+
+// monitorHealth keeps gRPC health serving status in line with readiness until the server shuts down.
+func (s *Server) monitorHealth() {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		if err := s.clusterService.Ready(context.Background()); err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+
+		// Health server ignores status changes after its shutdown, so racing with GracefulShutdown is harmless.
+		s.healthServer.SetServingStatus("", status)
+		s.healthServer.SetServingStatus(crondServiceName, status)
+
+		select {
+		case <-s.shutdownCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// joinCluster asks the existing cluster to add the current node as a voter through join addresses, until it succeeds
+// or the server shuts down. Followers forward the request to raft leader.
+func (s *Server) joinCluster() {
+	req := &types.AddVoterRequest{Id: string(s.raftLayer.LocalID()), Address: string(s.raftLayer.LocalAddr())}
+	if host, _, err := net.SplitHostPort(req.Address); err == nil && (host == "" || net.ParseIP(host).IsUnspecified()) {
+		logs.Warn("joinCluster advertises an unspecified address, set --raft-advertise: addr=%s", req.Address)
+	}
+
+	for {
+		for _, addr := range s.c.RaftJoin {
+			if err := joinThrough(addr, req); err != nil {
+				logs.Warn("joinCluster failed: addr=%s, err=%v", addr, err)
+				continue
+			}
+
+			logs.Info("joinCluster successfully: addr=%s, id=%s", addr, req.Id)
+			return
+		}
+
+		select {
+		case <-s.shutdownCh:
+			return
+		case <-time.After(raftJoinRetryInterval):
+		}
+	}
+}
+
+func joinThrough(addr string, req *types.AddVoterRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), raftJoinTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = types.NewCrondClient(conn).AddVoter(ctx, req)
+	return err
+}
+
+func (s *Server) startDispatcher() {
+	logs.Info("CronD server acquired raft leadership, starting CronDispatcher")
+
+	// Make sure JobFSM has applied all committed logs before loading jobs. Nothing else starts CronDispatcher while
+	// the node stays leader, so the barrier is retried until leadership is lost or the server shuts down.
+	for {
+		err := s.raftLayer.Barrier()
+		if err == nil {
+			break
+		}
+
+		logs.Error("startDispatcher failed to wait for raft barrier: err=%v", err)
+		if !s.raftLayer.IsLeader() {
+			return
+		}
+
+		select {
+		case <-s.shutdownCh:
+			return
+		case <-time.After(raftBarrierRetryInterval):
+		}
+	}
+
+	// The server may start shutting down while waiting, CronDispatcher must not be restarted after its drain. Start
+	// is done under shutdownMu, so it either completes before GracefulShutdown drains or does not happen at all.
+	s.shutdownMu.Lock()
+	defer s.shutdownMu.Unlock()
+
+	select {
+	case <-s.shutdownCh:
+		return
+	default:
+	}
+
+	if err := s.dispatcher.Start(context.Background(), s.fsm); err != nil {
+		logs.Error("startDispatcher failed to start CronDispatcher: err=%v", err)
+	}
+}
+
+func (s *Server) stopDispatcher() {
+	logs.Info("CronD server lost raft leadership, stopping CronDispatcher")
+
+	ctx, cancel := context.WithTimeout(context.Background(), dispatcherStopTimeout)
+	defer cancel()
+
+	if err := s.dispatcher.Stop(ctx); err != nil {
+		logs.Error("stopDispatcher failed to drain in-flight runs: err=%v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KevinWu0904/crond/internal/common/constant"
+	"github.com/KevinWu0904/crond/pkg/logs"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+const (
+	// TraceExporterNone disables exporting, trace context is still propagated.
+	TraceExporterNone = "none"
+	// TraceExporterStdout prints spans to stdout, it is meant for local testing.
+	TraceExporterStdout = "stdout"
+	// TraceExporterOTLP exports spans to an OTLP gRPC endpoint, e.g. OpenTelemetry Collector.
+	TraceExporterOTLP = "otlp"
+)
+
+// tracerServiceName is the service name reported in spans and resource.
+const tracerServiceName = "crond"
+
+// tracer starts spans of crond server, it delegates to the tracer provider installed by InitTracing.
+var tracer = otel.Tracer("github.com/KevinWu0904/crond/internal/server")
+
+// InitTracing installs the global tracer provider exporting spans as configured and W3C trace context propagator,
+// the returned function flushes pending spans on shutdown.
+func InitTracing(c *Config) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch c.TraceExporter {
+	case "", TraceExporterNone:
+		return func(ctx context.Context) error { return nil }, nil
+	case TraceExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case TraceExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(c.TraceOTLPEndpoint)}
+		if c.TraceOTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(context.Background(), opts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", c.TraceExporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceNameKey.String(tracerServiceName),
+		semconv.ServiceInstanceIDKey.String(c.RaftNode),
+	)
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.TraceSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// mapCarrier implements propagation.TextMapCarrier interface, it carries trace context in raft commands.
+type mapCarrier map[string]string
+
+// Get implements propagation.TextMapCarrier interface.
+func (c mapCarrier) Get(key string) string {
+	return c[key]
+}
+
+// Set implements propagation.TextMapCarrier interface.
+func (c mapCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys implements propagation.TextMapCarrier interface.
+func (c mapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// withTraceKVs puts IDs of the current span into logs context KVs, so logs can be correlated with traces.
+func withTraceKVs(ctx context.Context) context.Context {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ctx
+	}
+
+	return logs.CtxAddKVs(ctx, constant.LogTraceID, sc.TraceID().String(), constant.LogSpanID, sc.SpanID().String())
+}
+
+// HTTPTraceKVs is a gin middleware putting IDs of the request span into logs context KVs, it must be used after the
+// tracing middleware.
+func HTTPTraceKVs(c *gin.Context) {
+	c.Request = c.Request.WithContext(withTraceKVs(c.Request.Context()))
+	c.Next()
+}
+
+// UnaryServerTraceKVs is a gRPC unary interceptor putting IDs of the request span into logs context KVs, it must be
+// chained after the tracing interceptor.
+func UnaryServerTraceKVs(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withTraceKVs(ctx), req)
+}
+
+// StreamServerTraceKVs is a gRPC stream interceptor putting IDs of the request span into logs context KVs, it must
+// be chained after the tracing interceptor.
+func StreamServerTraceKVs(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &tracedServerStream{ServerStream: ss, ctx: withTraceKVs(ss.Context())})
+}
+
+// tracedServerStream overrides the context of grpc.ServerStream.
+type tracedServerStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+// Context implements grpc.ServerStream interface.
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}